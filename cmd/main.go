@@ -16,8 +16,11 @@ import (
 	"music-service/internal/delivery/router"
 	"music-service/internal/repository"
 	"music-service/internal/service"
+	"music-service/pkg/cursor"
 	"music-service/pkg/database"
+	"music-service/pkg/explicit"
 	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
 	"music-service/pkg/utils"
 
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -59,10 +62,39 @@ func main() {
 	loggers.InfoLogger.Info("Migrations applied successfully")
 
 	songRepo := repository.NewSongRepository(db, loggers)
-	songService := service.NewSongService(songRepo, loggers)
-	songHandler := handler.NewSongHandler(songService, loggers)
+	normalizer, err := lyrics.NewNormalizer(cfg.Lyrics.NormalizeSteps)
+	if err != nil {
+		loggers.ErrorLogger.Error("Invalid lyrics configuration", utils.Err(err))
+		os.Exit(1)
+	}
+	explicitLists := explicit.DefaultLists
+	if cfg.Lyrics.ExplicitWordsFile != "" {
+		explicitLists, err = explicit.LoadLists(cfg.Lyrics.ExplicitWordsFile)
+		if err != nil {
+			loggers.ErrorLogger.Error("Invalid lyrics configuration", utils.Err(err))
+			os.Exit(1)
+		}
+	}
+	songService := service.NewSongService(songRepo, cursor.NewCodec(cfg.Pagination.CursorSecret), normalizer, explicit.NewDetector(explicitLists), cfg.Bulk.MaxAffected, loggers)
+	songHandler := handler.NewSongHandler(songService, cfg.HTTP.RequireIfMatch, loggers)
+
+	savedSearchRepo := repository.NewSavedSearchRepository(db, loggers)
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, songService, service.NewWebhookClient(cfg.Webhook.Timeout), loggers)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService, loggers)
+
+	annotationRepo := repository.NewAnnotationRepository(db, loggers)
+	annotationService := service.NewAnnotationService(annotationRepo, songRepo, loggers)
+	annotationHandler := handler.NewAnnotationHandler(annotationService, loggers)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db, loggers)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.KeyTTL, loggers)
+
+	notifierCtx, stopNotifier := context.WithCancel(context.Background())
+	defer stopNotifier()
+	go savedSearchService.RunNotifier(notifierCtx, cfg.Webhook.NotifyInterval)
+	go idempotencyService.RunPurger(notifierCtx, cfg.Idempotency.PurgeInterval)
 
-	r := router.NewRouter(songHandler)
+	r := router.NewRouter(songHandler, savedSearchHandler, annotationHandler, handler.Idempotency(idempotencyService, loggers))
 
 	// Serve Swagger API documentation
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
@@ -0,0 +1,110 @@
+// Command maintenance runs one-off maintenance tasks against the music
+// service database, e.g.
+//
+//	go run ./cmd/maintenance reindex-verses
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+
+	"music-service/internal/config"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/cursor"
+	"music-service/pkg/database"
+	"music-service/pkg/explicit"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+)
+
+var tasks = map[string]struct {
+	description string
+	run         func(ctx context.Context, songService service.SongService) (int, error)
+}{
+	"reindex-verses": {
+		description: "rebuild the stored stanzas of every song from its lyrics",
+		run: func(ctx context.Context, songService service.SongService) (int, error) {
+			return songService.ReindexVerses(ctx)
+		},
+	},
+	"detect-explicit": {
+		description: "re-run explicit-content detection over every song, keeping manual flags",
+		run: func(ctx context.Context, songService service.SongService) (int, error) {
+			return songService.DetectExplicit(ctx)
+		},
+	},
+	"renormalize": {
+		description: "re-apply lyrics normalization to the submitted text of every song",
+		run: func(ctx context.Context, songService service.SongService) (int, error) {
+			return songService.Renormalize(ctx)
+		},
+	},
+	"detect-language": {
+		description: "re-detect the language of every song without a manual override",
+		run: func(ctx context.Context, songService service.SongService) (int, error) {
+			return songService.DetectLanguages(ctx)
+		},
+	},
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		usage()
+	}
+
+	task, ok := tasks[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	cfg := config.MustLoadConfig()
+
+	loggers, err := logger.SetupLogger(cfg.Logger.Level)
+	if err != nil {
+		log.Fatalf("Could not set up logger: %v", err)
+	}
+
+	dbConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name)
+
+	db, err := database.NewDatabase(dbConnStr)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	defer database.CloseDatabase(db)
+
+	songRepo := repository.NewSongRepository(db, loggers)
+	normalizer, err := lyrics.NewNormalizer(cfg.Lyrics.NormalizeSteps)
+	if err != nil {
+		log.Fatalf("Invalid lyrics configuration: %v", err)
+	}
+	explicitLists := explicit.DefaultLists
+	if cfg.Lyrics.ExplicitWordsFile != "" {
+		explicitLists, err = explicit.LoadLists(cfg.Lyrics.ExplicitWordsFile)
+		if err != nil {
+			log.Fatalf("Invalid lyrics configuration: %v", err)
+		}
+	}
+	songService := service.NewSongService(songRepo, cursor.NewCodec(cfg.Pagination.CursorSecret), normalizer, explicit.NewDetector(explicitLists), cfg.Bulk.MaxAffected, loggers)
+
+	count, err := task.run(context.Background(), songService)
+	if err != nil {
+		log.Fatalf("%s failed after %d songs: %v", os.Args[1], count, err)
+	}
+
+	log.Printf("%s: processed %d songs", os.Args[1], count)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: maintenance <task>")
+	fmt.Fprintln(os.Stderr, "tasks:")
+	for _, name := range slices.Sorted(maps.Keys(tasks)) {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, tasks[name].description)
+	}
+	os.Exit(2)
+}
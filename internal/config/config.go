@@ -9,14 +9,20 @@ import (
 )
 
 type Config struct {
-	HTTP     HTTPConfig
-	Database DatabaseConfig
-	Logger   LoggerConfig
+	HTTP        HTTPConfig
+	Database    DatabaseConfig
+	Logger      LoggerConfig
+	Pagination  PaginationConfig
+	Webhook     WebhookConfig
+	Lyrics      LyricsConfig
+	Bulk        BulkConfig
+	Idempotency IdempotencyConfig
 }
 
 type HTTPConfig struct {
-	Port    int           `env:"HTTP_PORT" env-required:"true"`
-	Timeout time.Duration `env:"HTTP_TIMEOUT" env-required:"true"`
+	Port           int           `env:"HTTP_PORT" env-required:"true"`
+	Timeout        time.Duration `env:"HTTP_TIMEOUT" env-required:"true"`
+	RequireIfMatch bool          `env:"HTTP_REQUIRE_IF_MATCH" env-default:"false"`
 }
 
 type DatabaseConfig struct {
@@ -31,6 +37,29 @@ type LoggerConfig struct {
 	Level string `env:"LOGGER_LEVEL" env-required:"true"`
 }
 
+type PaginationConfig struct {
+	CursorSecret string `env:"PAGINATION_CURSOR_SECRET" env-required:"true"`
+}
+
+type WebhookConfig struct {
+	NotifyInterval time.Duration `env:"WEBHOOK_NOTIFY_INTERVAL" env-default:"1m"`
+	Timeout        time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+}
+
+type LyricsConfig struct {
+	NormalizeSteps    []string `env:"LYRICS_NORMALIZE_STEPS" env-separator:"," env-default:"line_endings,zero_width,nfc,quotes,trailing_space,blank_lines"`
+	ExplicitWordsFile string   `env:"LYRICS_EXPLICIT_WORDS_FILE"`
+}
+
+type BulkConfig struct {
+	MaxAffected int `env:"BULK_MAX_AFFECTED" env-default:"1000"`
+}
+
+type IdempotencyConfig struct {
+	KeyTTL        time.Duration `env:"IDEMPOTENCY_KEY_TTL" env-default:"24h"`
+	PurgeInterval time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" env-default:"1h"`
+}
+
 func LoadConfig() (*Config, error) {
 	err := godotenv.Load(".env")
 	if err != nil {
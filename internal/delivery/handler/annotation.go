@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"music-service/internal/domain"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AnnotationHandler struct {
+	annotationService service.AnnotationService
+	loggers           *logger.Loggers
+}
+
+func NewAnnotationHandler(annotationService service.AnnotationService, loggers *logger.Loggers) *AnnotationHandler {
+	return &AnnotationHandler{annotationService: annotationService, loggers: loggers}
+}
+
+type CreateAnnotationRequest struct {
+	Author string        `json:"author"`
+	Body   string        `json:"body"`
+	Anchor lyrics.Anchor `json:"anchor"`
+}
+
+type VoteRequest struct {
+	Voter string `json:"voter"`
+	Vote  int    `json:"vote"`
+}
+
+// GetAnnotations godoc
+// @Summary List a song's annotations
+// @Description Return all annotations of a song ordered by position. Annotations whose anchored text was removed by an edit are returned with orphaned set.
+// @Tags annotations
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {array} domain.Annotation
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch annotations"
+// @Router /songs/{id}/annotations [get]
+func (h *AnnotationHandler) GetAnnotations(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.pathID(w, r, "id", "Invalid song ID")
+	if !ok {
+		return
+	}
+
+	annotations, err := h.annotationService.GetAnnotations(r.Context(), songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch annotations")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, annotations)
+}
+
+// GetAnnotation godoc
+// @Summary Get an annotation
+// @Tags annotations
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param annotationID path int true "Annotation ID"
+// @Success 200 {object} domain.Annotation
+// @Failure 400 {object} utils.Problem "Invalid ID"
+// @Failure 404 {object} utils.Problem "Annotation not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch annotation"
+// @Router /songs/{id}/annotations/{annotationID} [get]
+func (h *AnnotationHandler) GetAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.pathID(w, r, "id", "Invalid song ID")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(w, r, "annotationID", "Invalid annotation ID")
+	if !ok {
+		return
+	}
+
+	annotation, err := h.annotationService.GetAnnotation(r.Context(), songID, id)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch annotation")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, annotation)
+}
+
+// CreateAnnotation godoc
+// @Summary Annotate lyrics
+// @Description Attach a Markdown annotation to runes [start, end) of a lyric line, or of a whole stanza when line is omitted. Leaving start and end at 0 anchors the whole line or stanza.
+// @Tags annotations
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param annotation body CreateAnnotationRequest true "Annotation"
+// @Success 201 {object} domain.Annotation
+// @Header 201 {string} Location "/songs/{id}/annotations/{annotationID}"
+// @Failure 400 {object} utils.Problem "Invalid song ID or payload"
+// @Failure 422 {object} utils.Problem "Invalid annotation"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to create annotation"
+// @Router /songs/{id}/annotations [post]
+func (h *AnnotationHandler) CreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.pathID(w, r, "id", "Invalid song ID")
+	if !ok {
+		return
+	}
+
+	var req CreateAnnotationRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	created, err := h.annotationService.CreateAnnotation(r.Context(), domain.Annotation{
+		SongID: songID,
+		Author: req.Author,
+		Body:   req.Body,
+		Anchor: req.Anchor,
+	})
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to create annotation")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Created annotation successfully", slog.Int("id", created.ID))
+	w.Header().Set("Location", fmt.Sprintf("/songs/%d/annotations/%d", songID, created.ID))
+	utils.RespondWithJSON(w, http.StatusCreated, created)
+}
+
+// DeleteAnnotation godoc
+// @Summary Delete an annotation
+// @Tags annotations
+// @Param id path int true "Song ID"
+// @Param annotationID path int true "Annotation ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} utils.Problem "Invalid ID"
+// @Failure 404 {object} utils.Problem "Annotation not found"
+// @Failure 500 {object} utils.Problem "Failed to delete annotation"
+// @Router /songs/{id}/annotations/{annotationID} [delete]
+func (h *AnnotationHandler) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.pathID(w, r, "id", "Invalid song ID")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(w, r, "annotationID", "Invalid annotation ID")
+	if !ok {
+		return
+	}
+
+	if err := h.annotationService.DeleteAnnotation(r.Context(), songID, id); err != nil {
+		respondError(w, r, h.loggers, err, "Failed to delete annotation")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// VoteAnnotation godoc
+// @Summary Vote on an annotation
+// @Description Up-vote (1) or down-vote (-1) an annotation and return it with its new score. Each voter has one vote per annotation; voting again replaces it.
+// @Tags annotations
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param annotationID path int true "Annotation ID"
+// @Param vote body VoteRequest true "Vote"
+// @Success 200 {object} domain.Annotation
+// @Failure 400 {object} utils.Problem "Invalid ID or payload"
+// @Failure 422 {object} utils.Problem "Invalid vote or voter"
+// @Failure 404 {object} utils.Problem "Annotation not found"
+// @Failure 500 {object} utils.Problem "Failed to record vote"
+// @Router /songs/{id}/annotations/{annotationID}/votes [post]
+func (h *AnnotationHandler) VoteAnnotation(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.pathID(w, r, "id", "Invalid song ID")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(w, r, "annotationID", "Invalid annotation ID")
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	annotation, err := h.annotationService.VoteAnnotation(r.Context(), songID, id, req.Voter, req.Vote)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to record vote")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, annotation)
+}
+
+func (h *AnnotationHandler) pathID(w http.ResponseWriter, r *http.Request, param, message string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, param))
+	if err != nil {
+		h.loggers.ErrorLogger.Error(message, utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"log/slog"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+// BulkRequest selects songs like a saved search does, with a filter and a
+// q expression, and names the operation to apply to them.
+type BulkRequest struct {
+	Filter    repository.SongFilter `json:"filter"`
+	Query     string                `json:"query,omitempty"`
+	Operation service.BulkOperation `json:"operation"`
+	DryRun    bool                  `json:"dry_run"`
+}
+
+// BulkResponse reports a bulk operation. Sample shows the first changed
+// songs as they are after the change, or as they were for deletes.
+type BulkResponse struct {
+	DryRun        bool          `json:"dry_run"`
+	Matched       int           `json:"matched"`
+	Affected      int           `json:"affected"`
+	LimitExceeded bool          `json:"limit_exceeded,omitempty"`
+	Sample        []domain.Song `json:"sample"`
+}
+
+// BulkUpdateSongs godoc
+// @Summary Change or delete songs in bulk
+// @Description Apply one operation to every song a filter selects: delete, set (group, song, release_date, link or language), add_tag, or replace_text with a regular expression over the lyrics as submitted, which are then normalized again. The filter takes the GET /songs filters and a q expression as query, and must not be empty. The change runs in one transaction, is refused when the filter matches more songs than the configured maximum, and is recorded in the audit log. With dry_run the change is made and rolled back, reporting the exact counts and a sample; a dry run over the maximum reports the match count and limit_exceeded instead.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param request body BulkRequest true "Filter, operation and dry_run"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} utils.Problem "Invalid request payload"
+// @Failure 422 {object} utils.Problem "Invalid filter or operation, or too many songs match"
+// @Failure 500 {object} utils.Problem "Failed to apply bulk operation"
+// @Router /songs/bulk [post]
+func (h *SongHandler) BulkUpdateSongs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling BulkUpdateSongs request")
+
+	var req BulkRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	filter := req.Filter
+	filter.Query = req.Query
+	result, err := h.songService.BulkUpdate(ctx, filter, req.Operation, req.DryRun)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to apply bulk operation")
+		return
+	}
+
+	response := BulkResponse{
+		DryRun:        req.DryRun,
+		Matched:       result.Matched,
+		Affected:      result.Affected,
+		LimitExceeded: result.LimitExceeded,
+		Sample:        result.Sample,
+	}
+	if response.Sample == nil {
+		response.Sample = []domain.Song{}
+	}
+
+	h.loggers.InfoLogger.Info("Applied bulk operation successfully", slog.String("type", req.Operation.Type), slog.Int("affected", result.Affected), slog.Bool("dryRun", req.DryRun))
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+// Problem types reported for the domain error kinds. They are relative
+// URI references, resolved against the API's base URL.
+const (
+	problemNotFound             = "/problems/not-found"
+	problemConflict             = "/problems/conflict"
+	problemValidation           = "/problems/validation"
+	problemUnavailable          = "/problems/unavailable"
+	problemPreconditionFailed   = "/problems/precondition-failed"
+	problemPreconditionRequired = "/problems/precondition-required"
+)
+
+// errorProblem maps an error returned by a service to the problem reported
+// for it by its domain kind. Unclassified errors are internal.
+func errorProblem(err error) utils.Problem {
+	switch {
+	case errors.Is(err, service.ErrVerseHashRequired):
+		return utils.Problem{Type: problemPreconditionRequired, Status: http.StatusPreconditionRequired}
+	case errors.Is(err, service.ErrVerseModified), errors.Is(err, repository.ErrSongModified):
+		return utils.Problem{Type: problemPreconditionFailed, Status: http.StatusPreconditionFailed}
+	case errors.Is(err, domain.ErrNotFound):
+		return utils.Problem{Type: problemNotFound, Status: http.StatusNotFound}
+	case errors.Is(err, domain.ErrConflict):
+		return utils.Problem{Type: problemConflict, Status: http.StatusConflict}
+	case errors.Is(err, domain.ErrValidation):
+		return utils.Problem{Type: problemValidation, Title: "Validation failed", Status: http.StatusUnprocessableEntity}
+	case errors.Is(err, domain.ErrUnavailable):
+		return utils.Problem{Type: problemUnavailable, Status: http.StatusServiceUnavailable}
+	default:
+		return utils.Problem{Status: http.StatusInternalServerError}
+	}
+}
+
+// respondError writes the problem response for an error returned by a
+// service.
+func respondError(w http.ResponseWriter, r *http.Request, loggers *logger.Loggers, err error, message string) {
+	utils.RespondWithProblem(w, r, newProblem(loggers, err, message))
+}
+
+// decodeJSON reads the JSON request body into v and answers the request
+// itself when it cannot: with 400 for a body that is not JSON and with 422
+// for unknown or mistyped fields.
+func decodeJSON(w http.ResponseWriter, r *http.Request, loggers *logger.Loggers, v any) bool {
+	return decoded(w, r, loggers, service.DecodeJSON(r.Body, v))
+}
+
+// decoded answers the request for an error of service.DecodeJSON or
+// service.DecodeSong and reports whether the body was decoded.
+func decoded(w http.ResponseWriter, r *http.Request, loggers *logger.Loggers, err error) bool {
+	if errors.Is(err, service.ErrMalformedPayload) {
+		loggers.ErrorLogger.Error("Invalid request payload", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid request payload")
+		return false
+	}
+	if err != nil {
+		respondError(w, r, loggers, err, "Invalid request payload")
+		return false
+	}
+	return true
+}
+
+// dbProblemDetails describe the client errors the database reports, whose
+// own messages name tables, constraints and values.
+var dbProblemDetails = map[int]string{
+	http.StatusConflict:            "The change conflicts with the current state of the data",
+	http.StatusUnprocessableEntity: "A value was rejected as malformed or out of range",
+}
+
+// newProblem builds the problem reported for an error returned by a
+// service. Client errors carry the error's own message and, for invalid
+// input, every offending field; server errors and errors reported by the
+// database are logged and described by a fixed text instead, so internals
+// do not leak.
+func newProblem(loggers *logger.Loggers, err error, message string) utils.Problem {
+	problem := errorProblem(err)
+	var dbErr *repository.DBError
+	switch {
+	case problem.Status == http.StatusInternalServerError:
+		loggers.ErrorLogger.Error(message, utils.Err(err))
+		problem.Detail = message
+	case problem.Status == http.StatusServiceUnavailable:
+		loggers.ErrorLogger.Error(message, utils.Err(err))
+		problem.Detail = "Service temporarily unavailable, please retry"
+	case errors.As(err, &dbErr):
+		loggers.ErrorLogger.Error(message, utils.Err(err))
+		problem.Detail = dbProblemDetails[problem.Status]
+		if problem.Detail == "" {
+			problem.Detail = message
+		}
+	default:
+		problem.Detail = err.Error()
+	}
+
+	var invalid *domain.ValidationError
+	if errors.As(err, &invalid) {
+		problem.Detail = "One or more fields are invalid"
+		for _, f := range invalid.Fields {
+			problem.Errors = append(problem.Errors, utils.InvalidField{Field: f.Field, Code: f.Code, Message: f.Message})
+		}
+	}
+	return problem
+}
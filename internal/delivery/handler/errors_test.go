@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", fmt.Errorf("loading: %w", repository.ErrSongNotFound), http.StatusNotFound},
+		{"conflict", domain.Conflict(errors.New("taken")), http.StatusConflict},
+		{"validation", domain.Validation(errors.New("bad")), http.StatusUnprocessableEntity},
+		{"invalid fields", &domain.ValidationError{Fields: []domain.FieldError{{Field: "song"}}}, http.StatusUnprocessableEntity},
+		{"unavailable", domain.Unavailable(errors.New("down")), http.StatusServiceUnavailable},
+		{"modified song", repository.ErrSongModified, http.StatusPreconditionFailed},
+		{"modified verse", service.ErrVerseModified, http.StatusPreconditionFailed},
+		{"verse hash required", service.ErrVerseHashRequired, http.StatusPreconditionRequired},
+		{"unclassified", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorProblem(tt.err).Status; got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewProblemHidesInternals(t *testing.T) {
+	loggers := newTestLoggers(t)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"client error", domain.Conflict(errors.New("song already exists")), "song already exists"},
+		{"database conflict", domain.Conflict(&repository.DBError{Err: errors.New(`duplicate key value violates unique constraint "songs_pkey"`)}), dbProblemDetails[http.StatusConflict]},
+		{"database outage", domain.Unavailable(&repository.DBError{Err: errors.New("dial tcp 10.0.0.1:5432: refused")}), "Service temporarily unavailable, please retry"},
+		{"internal", errors.New(`relation "songs" does not exist`), "Failed to fetch song"},
+	}
+	for _, tt := range tests {
+		if got := newProblem(loggers, tt.err, "Failed to fetch song").Detail; got != tt.want {
+			t.Errorf("%s: detail = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRespondErrorWritesProblemJSON(t *testing.T) {
+	err := &domain.ValidationError{Fields: []domain.FieldError{
+		{Field: "song", Code: "required", Message: "is required"},
+		{Field: "link", Code: "invalid_format", Message: "must be an http or https URL"},
+	}}
+	w := httptest.NewRecorder()
+	respondError(w, httptest.NewRequest(http.MethodPost, "/songs", nil), newTestLoggers(t), err, "Failed to add song")
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %s, want application/problem+json", ct)
+	}
+
+	var problem utils.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("decoding problem: %v", err)
+	}
+	want := utils.Problem{
+		Type:     problemValidation,
+		Title:    "Validation failed",
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   "One or more fields are invalid",
+		Instance: "/songs",
+		Errors: []utils.InvalidField{
+			{Field: "song", Code: "required", Message: "is required"},
+			{Field: "link", Code: "invalid_format", Message: "must be an http or https URL"},
+		},
+	}
+	if !reflect.DeepEqual(problem, want) {
+		t.Errorf("problem = %+v, want %+v", problem, want)
+	}
+}
+
+func TestDecodeJSONRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		body  string
+		want  int
+		field string
+	}{
+		{`{"name": "x"`, http.StatusBadRequest, ""},
+		{`{"name": "x", "color": "red"}`, http.StatusUnprocessableEntity, "color"},
+		{`{"name": 1}`, http.StatusUnprocessableEntity, "name"},
+	}
+	for _, tt := range tests {
+		var v struct {
+			Name string `json:"name"`
+		}
+		w := httptest.NewRecorder()
+		if decodeJSON(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), newTestLoggers(t), &v) {
+			t.Errorf("%s: decoded", tt.body)
+			continue
+		}
+
+		var problem utils.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.body, w.Code, tt.want)
+		}
+		if tt.field != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.field) {
+			t.Errorf("%s: errors = %+v, want one on %s", tt.body, problem.Errors, tt.field)
+		}
+	}
+}
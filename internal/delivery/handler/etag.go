@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"music-service/internal/domain"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// songETag is the strong entity tag of a song's current version.
+func songETag(song *domain.Song) string {
+	return strconv.Quote(strconv.Itoa(song.Version))
+}
+
+// ifMatchVersion reads the song version a write expects from If-Match. It
+// returns 0 when any version will do: for "*", or for a missing header
+// unless the handler requires one, in which case it answers 428. A tag that
+// is not a song version cannot match and is answered with 412.
+func (h *SongHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch tag {
+	case "":
+		if h.requireIfMatch {
+			utils.RespondWithErrorJSON(w, r, http.StatusPreconditionRequired, "If-Match with the song's ETag is required")
+			return 0, false
+		}
+		return 0, true
+	case "*":
+		return 0, true
+	}
+
+	// If-Match uses strong comparison, so weak tags never match.
+	unquoted, err := strconv.Unquote(tag)
+	version, convErr := strconv.Atoi(unquoted)
+	if err != nil || convErr != nil || version <= 0 {
+		utils.RespondWithErrorJSON(w, r, http.StatusPreconditionFailed, "If-Match does not match the song's current ETag")
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified reports whether If-None-Match lists etag, comparing weakly as
+// RFC 9110 asks for GET.
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithETag sends data with the given entity tag, or 304 Not Modified
+// if the client already holds it.
+func respondWithETag(w http.ResponseWriter, r *http.Request, etag string, data interface{}) {
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, data)
+}
+
+// contentETag is a weak entity tag derived from the JSON encoding of data,
+// for responses that do not map onto a single song version.
+func contentETag(data interface{}) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`, nil
+}
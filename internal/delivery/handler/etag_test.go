@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeSongService serves one song. Methods a test does not need fall
+// through to the nil embedded interface and panic.
+type fakeSongService struct {
+	service.SongService
+	song    domain.Song
+	deleted []int
+}
+
+func (s *fakeSongService) GetSongByID(ctx context.Context, songID int) (*domain.Song, error) {
+	if songID != s.song.ID {
+		return nil, repository.ErrSongNotFound
+	}
+	song := s.song
+	return &song, nil
+}
+
+func (s *fakeSongService) DeleteSong(ctx context.Context, songID, version int) error {
+	if songID != s.song.ID {
+		return repository.ErrSongNotFound
+	}
+	if version != 0 && version != s.song.Version {
+		return repository.ErrSongModified
+	}
+	s.deleted = append(s.deleted, version)
+	return nil
+}
+
+func newTestLoggers(t *testing.T) *logger.Loggers {
+	t.Helper()
+
+	loggers, err := logger.SetupLogger("test")
+	if err != nil {
+		t.Fatalf("SetupLogger: %v", err)
+	}
+	return loggers
+}
+
+// songRequest builds a request for the song route with the given id and
+// headers.
+func songRequest(method, id string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(method, "/songs/"+id, nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestGetSongETag(t *testing.T) {
+	h := NewSongHandler(&fakeSongService{song: domain.Song{ID: 1, Version: 3}}, false, newTestLoggers(t))
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{"", http.StatusOK},
+		{`"3"`, http.StatusNotModified},
+		{`W/"3"`, http.StatusNotModified},
+		{`"2", "3"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.GetSong(w, songRequest(http.MethodGet, "1", map[string]string{"If-None-Match": tt.ifNoneMatch}))
+
+		if w.Code != tt.want {
+			t.Errorf("If-None-Match %s: status = %d, want %d", tt.ifNoneMatch, w.Code, tt.want)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"3"` {
+			t.Errorf("If-None-Match %s: ETag = %s, want \"3\"", tt.ifNoneMatch, etag)
+		}
+		if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: 304 has a body", tt.ifNoneMatch)
+		}
+	}
+}
+
+func TestDeleteSongIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		requireIfMatch bool
+		ifMatch        string
+		want           int
+		version        int
+	}{
+		{"current", true, `"3"`, http.StatusOK, 3},
+		{"any", true, "*", http.StatusOK, 0},
+		{"optional", false, "", http.StatusOK, 0},
+		{"required", true, "", http.StatusPreconditionRequired, -1},
+		{"stale", false, `"2"`, http.StatusPreconditionFailed, -1},
+		{"weak", false, `W/"3"`, http.StatusPreconditionFailed, -1},
+		{"not a version", false, `"abc"`, http.StatusPreconditionFailed, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs := &fakeSongService{song: domain.Song{ID: 1, Version: 3}}
+			h := NewSongHandler(songs, tt.requireIfMatch, newTestLoggers(t))
+
+			w := httptest.NewRecorder()
+			h.DeleteSong(w, songRequest(http.MethodDelete, "1", map[string]string{"If-Match": tt.ifMatch}))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.version < 0 {
+				if len(songs.deleted) != 0 {
+					t.Errorf("song was deleted with version %v", songs.deleted)
+				}
+				return
+			}
+			if len(songs.deleted) != 1 || songs.deleted[0] != tt.version {
+				t.Errorf("deleted with versions %v, want [%d]", songs.deleted, tt.version)
+			}
+		})
+	}
+}
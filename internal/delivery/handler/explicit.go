@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"log/slog"
+	"music-service/internal/domain"
+	"music-service/pkg/explicit"
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+type ExplicitRequest struct {
+	Explicit *bool `json:"explicit"`
+}
+
+// ExplicitResponse reports whether a song is explicit, whether that was
+// detected or set by hand, and where its lyrics hit the word lists.
+type ExplicitResponse struct {
+	SongID   int            `json:"song_id"`
+	Explicit bool           `json:"explicit"`
+	Source   string         `json:"source,omitempty"`
+	Hits     []explicit.Hit `json:"hits"`
+}
+
+func newExplicitResponse(song *domain.Song) ExplicitResponse {
+	hits := song.ExplicitHits
+	if hits == nil {
+		hits = []explicit.Hit{}
+	}
+	return ExplicitResponse{SongID: song.ID, Explicit: song.Explicit, Source: song.ExplicitSource, Hits: hits}
+}
+
+// GetSongExplicit godoc
+// @Summary Explicit-content report of a song
+// @Description Return the song's explicit flag, its source (detected or manual) and the positions of words from the explicit word lists.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} ExplicitResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch song"
+// @Router /songs/{id}/explicit [get]
+func (h *SongHandler) GetSongExplicit(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	song, err := h.songService.GetSongByID(r.Context(), songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch song")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, newExplicitResponse(song))
+}
+
+// SetSongExplicit godoc
+// @Summary Override a song's explicit flag
+// @Description Flag the song as explicit or clean by hand. The manual flag survives lyric edits and re-detection.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param explicit body ExplicitRequest true "Explicit flag"
+// @Success 200 {object} ExplicitResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID or payload"
+// @Failure 422 {object} utils.Problem "Unknown or mistyped field"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to set explicit flag"
+// @Router /songs/{id}/explicit [put]
+func (h *SongHandler) SetSongExplicit(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ExplicitRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+	if req.Explicit == nil {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	h.setSongExplicit(w, r, songID, req.Explicit)
+}
+
+// ClearSongExplicit godoc
+// @Summary Remove a song's explicit override
+// @Description Drop the manual explicit flag and use the detected one again.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} ExplicitResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to set explicit flag"
+// @Router /songs/{id}/explicit [delete]
+func (h *SongHandler) ClearSongExplicit(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	h.setSongExplicit(w, r, songID, nil)
+}
+
+func (h *SongHandler) setSongExplicit(w http.ResponseWriter, r *http.Request, songID int, explicit *bool) {
+	song, err := h.songService.SetSongExplicit(r.Context(), songID, explicit)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to set explicit flag")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Set song explicit flag successfully", slog.Int("songID", songID), slog.Bool("explicit", song.Explicit))
+	utils.RespondWithJSON(w, http.StatusOK, newExplicitResponse(song))
+}
@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/utils"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._ -]+`)
+
+// safeFilename reduces s to characters that are safe in a file name and in
+// a quoted Content-Disposition parameter.
+func safeFilename(s string) string {
+	s = strings.TrimSpace(unsafeFilenameChars.ReplaceAllString(s, "_"))
+	if s == "" || strings.Trim(s, "._") == "" {
+		return "lyrics"
+	}
+	return s
+}
+
+// contentDisposition builds the attachment header for a file named base
+// plus ext. filename carries the ASCII fallback of safeFilename; a name
+// with other characters is also sent in full as RFC 5987 filename*.
+func contentDisposition(base, ext string) string {
+	header := mime.FormatMediaType("attachment", map[string]string{"filename": safeFilename(base) + ext})
+	name := strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '_'
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, base))
+	for _, r := range name {
+		if r > unicode.MaxASCII {
+			full := mime.FormatMediaType("attachment", map[string]string{"filename": name + ext})
+			return header + strings.TrimPrefix(full, "attachment")
+		}
+	}
+	return header
+}
+
+// ExportSongLyrics godoc
+// @Summary Export a song's lyrics
+// @Description Download lyrics as plain text, Markdown, SRT, WebVTT or JSON. Subtitle timings come from the song's synced lyrics when present and are estimated otherwise.
+// @Tags lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Param format query string false "Export format" Enums(txt, md, srt, vtt, json)
+// @Success 200 {string} string "Exported lyrics"
+// @Failure 400 {object} utils.Problem "Invalid song ID or format"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to export lyrics"
+// @Router /songs/{id}/lyrics/export [get]
+func (h *SongHandler) ExportSongLyrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling ExportSongLyrics request")
+
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	format, ok := h.exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	doc, err := h.songService.ExportSongLyrics(ctx, songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to export lyrics")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", contentDisposition(doc.Artist+" - "+doc.Title, "."+format.Extension()))
+	if err := lyrics.Export(w, *doc, format); err != nil {
+		h.loggers.ErrorLogger.Error("Failed to write lyrics export", utils.Err(err))
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Exported song lyrics successfully", slog.Int("songID", songID), slog.String("format", string(format)))
+}
+
+// ExportArtistLyrics godoc
+// @Summary Export an artist's lyrics
+// @Description Download the lyrics of every song by an artist as a zip archive with one file per song.
+// @Tags lyrics
+// @Produce application/zip
+// @Param name path string true "Artist name (exact, case-insensitive)"
+// @Param format query string false "Export format of the files in the archive" Enums(txt, md, srt, vtt, json)
+// @Success 200 {file} file "Zip archive"
+// @Failure 400 {object} utils.Problem "Invalid format"
+// @Failure 404 {object} utils.Problem "Artist not found"
+// @Failure 500 {object} utils.Problem "Failed to export lyrics"
+// @Router /artists/{name}/lyrics/export [get]
+func (h *SongHandler) ExportArtistLyrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling ExportArtistLyrics request")
+
+	artist := chi.URLParam(r, "name")
+
+	format, ok := h.exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	docs, err := h.songService.ExportArtistLyrics(ctx, artist)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to export lyrics")
+		return
+	}
+	if len(docs) == 0 {
+		utils.RespondWithErrorJSON(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", contentDisposition(docs[0].Artist+" lyrics", ".zip"))
+
+	archive := zip.NewWriter(w)
+	used := make(map[string]int)
+	for _, doc := range docs {
+		name := safeFilename(doc.Title)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+
+		f, err := archive.Create(name + "." + format.Extension())
+		if err == nil {
+			err = lyrics.Export(f, doc, format)
+		}
+		if err != nil {
+			// Headers are already sent; all we can do is stop writing.
+			h.loggers.ErrorLogger.Error("Failed to write lyrics archive", utils.Err(err))
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		h.loggers.ErrorLogger.Error("Failed to finish lyrics archive", utils.Err(err))
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Exported artist lyrics successfully", slog.String("artist", artist), slog.Int("songs", len(docs)))
+}
+
+func (h *SongHandler) exportFormat(w http.ResponseWriter, r *http.Request) (lyrics.Format, bool) {
+	raw := r.URL.Query().Get("format")
+	if raw == "" {
+		return lyrics.FormatText, true
+	}
+
+	format, err := lyrics.ParseFormat(raw)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return format, true
+}
+
+const (
+	// exportFlushRows and exportFlushInterval bound how much of a song
+	// export is buffered before it is flushed to the client.
+	exportFlushRows     = 500
+	exportFlushInterval = time.Second
+)
+
+// exportColumn renders one column of a song export, as a JSON value for
+// NDJSON and as text for CSV. CSV text matches what POST /songs/import
+// reads back.
+type exportColumn struct {
+	json func(song *domain.Song) interface{}
+	csv  func(song *domain.Song) string
+}
+
+var exportColumns = map[string]exportColumn{
+	"id":                  {func(s *domain.Song) interface{} { return s.ID }, func(s *domain.Song) string { return strconv.Itoa(s.ID) }},
+	"group":               {func(s *domain.Song) interface{} { return s.Group }, func(s *domain.Song) string { return s.Group }},
+	"song":                {func(s *domain.Song) interface{} { return s.Song }, func(s *domain.Song) string { return s.Song }},
+	"release_date":        {func(s *domain.Song) interface{} { return s.ReleaseDate }, func(s *domain.Song) string { return s.ReleaseDate.Format(time.DateOnly) }},
+	"text":                {func(s *domain.Song) interface{} { return s.Text }, func(s *domain.Song) string { return s.Text }},
+	"link":                {func(s *domain.Song) interface{} { return s.Link }, func(s *domain.Song) string { return s.Link }},
+	"tags":                {func(s *domain.Song) interface{} { return nonNil(s.Tags) }, func(s *domain.Song) string { return strings.Join(s.Tags, ";") }},
+	"language":            {func(s *domain.Song) interface{} { return s.Language }, func(s *domain.Song) string { return s.Language }},
+	"language_confidence": {func(s *domain.Song) interface{} { return s.LanguageConfidence }, func(s *domain.Song) string { return strconv.FormatFloat(s.LanguageConfidence, 'f', -1, 64) }},
+	"language_source":     {func(s *domain.Song) interface{} { return s.LanguageSource }, func(s *domain.Song) string { return s.LanguageSource }},
+	"explicit":            {func(s *domain.Song) interface{} { return s.Explicit }, func(s *domain.Song) string { return strconv.FormatBool(s.Explicit) }},
+	"explicit_source":     {func(s *domain.Song) interface{} { return s.ExplicitSource }, func(s *domain.Song) string { return s.ExplicitSource }},
+	"created_at":          {func(s *domain.Song) interface{} { return s.CreatedAt }, func(s *domain.Song) string { return s.CreatedAt.Format(time.RFC3339) }},
+	"version":             {func(s *domain.Song) interface{} { return s.Version }, func(s *domain.Song) string { return strconv.Itoa(s.Version) }},
+}
+
+// defaultExportColumns is the column order used when the client picks none.
+var defaultExportColumns = []string{"id", "group", "song", "release_date", "text", "link", "tags", "language", "language_confidence",
+	"language_source", "explicit", "explicit_source", "created_at", "version"}
+
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// ExportSongs godoc
+// @Summary Export the song catalog
+// @Description Stream every song matching the GET /songs filters as NDJSON or as CSV with a header row, in ID order. The export reads one consistent snapshot of the library however long it runs. If it fails midway the connection is aborted, so a truncated download is never mistaken for a complete one.
+// @Tags songs
+// @Produce application/x-ndjson,text/csv
+// @Param format query string false "Export format" Enums(ndjson, csv) default(ndjson)
+// @Param columns query string false "Comma-separated columns to include, in order: id, group, song, release_date, text, link, tags, language, language_confidence, language_source, explicit, explicit_source, created_at, version"
+// @Param artist query string false "Filter by exact artist name"
+// @Param group_name query string false "Filter by group name"
+// @Param song_name query string false "Filter by song name"
+// @Param release_date query string false "Filter by release date"
+// @Param decade query int false "Filter by decade, e.g. 1990"
+// @Param tag query string false "Filter by genre/tag"
+// @Param lang query string false "Filter by language code"
+// @Param clean query bool false "Exclude songs flagged as explicit"
+// @Param has_lyrics query bool false "Filter by presence of lyrics"
+// @Param q query string false "Search expression, as for GET /songs"
+// @Success 200 {string} string "Exported songs"
+// @Failure 400 {object} utils.Problem "Invalid filter, format or columns"
+// @Failure 422 {object} utils.Problem "Invalid search expression"
+// @Failure 500 {object} utils.Problem "Failed to export songs"
+// @Router /songs/export [get]
+func (h *SongHandler) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling ExportSongs request")
+
+	filter, err := parseSongFilter(r)
+	if err != nil {
+		respondFilterError(w, r, h.loggers, err)
+		return
+	}
+
+	exporter, err := newSongExporter(w, r.URL.Query().Get("format"), r.URL.Query().Get("columns"))
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid export options", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// An export streams for as long as the catalog takes to send, so the
+	// server's write timeout is lifted for it.
+	exporter.rc.SetWriteDeadline(time.Time{})
+
+	err = h.songService.ExportSongs(ctx, filter, exporter.write)
+	if err == nil {
+		err = exporter.close()
+	}
+	if err != nil && !exporter.started {
+		respondError(w, r, h.loggers, err, "Failed to export songs")
+		return
+	}
+	if err != nil {
+		// Headers are already sent; aborting the connection keeps the
+		// client from taking a truncated export for a complete one.
+		h.loggers.ErrorLogger.Error("Failed to write song export", utils.Err(err))
+		panic(http.ErrAbortHandler)
+	}
+
+	h.loggers.InfoLogger.Info("Exported songs successfully", slog.Int("count", exporter.rows), slog.String("format", exporter.format))
+}
+
+// songExporter writes songs to an export response, sending the headers
+// with the first song and flushing periodically.
+type songExporter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  string
+	columns []string
+	csv     *csv.Writer
+	buf     bytes.Buffer
+	started bool
+	rows    int
+	pending int
+	flushed time.Time
+}
+
+func newSongExporter(w http.ResponseWriter, format, columns string) (*songExporter, error) {
+	e := &songExporter{w: w, rc: http.NewResponseController(w), format: format, columns: defaultExportColumns}
+	switch format {
+	case "":
+		e.format = "ndjson"
+	case "ndjson":
+	case "csv":
+		e.csv = csv.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("invalid format %q", format)
+	}
+
+	if columns != "" {
+		e.columns = nil
+		seen := make(map[string]bool)
+		for _, name := range strings.Split(columns, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := exportColumns[name]; !ok {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+			if !seen[name] {
+				seen[name] = true
+				e.columns = append(e.columns, name)
+			}
+		}
+	}
+	return e, nil
+}
+
+func (e *songExporter) start() error {
+	e.started = true
+	e.flushed = time.Now()
+	if e.csv == nil {
+		e.w.Header().Set("Content-Type", "application/x-ndjson")
+		e.w.Header().Set("Content-Disposition", `attachment; filename="songs.ndjson"`)
+		e.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.w.Header().Set("Content-Disposition", `attachment; filename="songs.csv"`)
+	e.w.WriteHeader(http.StatusOK)
+	return e.csv.Write(e.columns)
+}
+
+func (e *songExporter) write(song domain.Song) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if e.csv != nil {
+		record := make([]string, len(e.columns))
+		for i, name := range e.columns {
+			record[i] = exportColumns[name].csv(&song)
+		}
+		if err := e.csv.Write(record); err != nil {
+			return err
+		}
+	} else {
+		// The object is assembled by hand to keep the requested column
+		// order, which a map would lose.
+		e.buf.Reset()
+		e.buf.WriteByte('{')
+		for i, name := range e.columns {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			value, err := json.Marshal(exportColumns[name].json(&song))
+			if err != nil {
+				return err
+			}
+			e.buf.WriteString(strconv.Quote(name))
+			e.buf.WriteByte(':')
+			e.buf.Write(value)
+		}
+		e.buf.WriteString("}\n")
+		if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	e.rows++
+	e.pending++
+	if e.pending >= exportFlushRows || time.Since(e.flushed) >= exportFlushInterval {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *songExporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	e.pending = 0
+	e.flushed = time.Now()
+	// Writers that cannot flush still deliver everything at the end.
+	e.rc.Flush()
+	return nil
+}
+
+// close finishes the export, sending the headers of an empty one.
+func (e *songExporter) close() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
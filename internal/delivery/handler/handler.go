@@ -1,89 +1,189 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"music-service/internal/domain"
 	"music-service/internal/repository"
 	"music-service/internal/service"
 	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/patch"
 	"music-service/pkg/utils"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type SongHandler struct {
-	songService service.SongService
-	loggers     *logger.Loggers
+	songService    service.SongService
+	requireIfMatch bool
+	loggers        *logger.Loggers
 }
 
-func NewSongHandler(songService service.SongService, loggers *logger.Loggers) *SongHandler {
-	return &SongHandler{songService: songService, loggers: loggers}
+// NewSongHandler creates the song handler. With requireIfMatch, writes to a
+// song must carry its ETag in If-Match.
+func NewSongHandler(songService service.SongService, requireIfMatch bool, loggers *logger.Loggers) *SongHandler {
+	return &SongHandler{songService: songService, requireIfMatch: requireIfMatch, loggers: loggers}
+}
+
+// SongListResponse is the envelope returned by GET /songs.
+type SongListResponse struct {
+	Data   []SongItem                          `json:"data"`
+	Page   PageInfo                            `json:"page"`
+	Facets map[string][]repository.FacetBucket `json:"facets,omitempty"`
+}
+
+// SongItem is a song in a listing together with its ETag.
+type SongItem struct {
+	domain.Song
+	ETag string `json:"etag"`
+}
+
+type PageInfo struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor,omitempty"`
+	Total      *int    `json:"total,omitempty"`
 }
 
 // GetSongs godoc
 // @Summary Get songs with optional filtering and pagination
-// @Description Retrieve songs filtered by group, song name, and/or release date with pagination.
+// @Description Retrieve songs filtered by group, song name, and/or release date. Pages are navigated with the opaque next_cursor/prev_cursor tokens (also sent in the Link header); offset is still accepted for older clients.
 // @Tags songs
 // @Accept json
 // @Produce json
+// @Param artist query string false "Filter by exact artist name"
 // @Param group_name query string false "Filter by group name"
 // @Param song_name query string false "Filter by song name"
 // @Param release_date query string false "Filter by release date"
+// @Param decade query int false "Filter by decade, e.g. 1990"
+// @Param tag query string false "Filter by genre/tag"
+// @Param lang query string false "Filter by language code"
+// @Param clean query bool false "Exclude songs flagged as explicit"
+// @Param has_lyrics query bool false "Filter by presence of lyrics"
+// @Param q query string false "Search expression, e.g. artist:rammstein AND year>=1997 AND NOT lyrics:\"sonne\". Fields: id, artist, song, lyrics, link, year, date, tag, lang"
+// @Param facets query string false "Comma-separated facets to count (artist, decade, tag, language, has_lyrics), each optionally suffixed with :limit"
 // @Param limit query int false "Pagination limit"
-// @Param offset query int false "Pagination offset"
-// @Success 200 {array} domain.Song
-// @Failure 500 {object} utils.JSONError "Failed to fetch songs"
+// @Param cursor query string false "Pagination cursor from a previous response"
+// @Param offset query int false "Pagination offset (ignored when cursor is set)"
+// @Param include_total query bool false "Include the total number of matching songs"
+// @Success 200 {object} SongListResponse
+// @Failure 400 {object} utils.Problem "Invalid filter or facet"
+// @Failure 422 {object} utils.Problem "Invalid cursor or search expression"
+// @Failure 500 {object} utils.Problem "Failed to fetch songs"
 // @Router /songs [get]
 func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	h.loggers.DebugLogger.Debug("Handling GetSongs request")
 
-	groupName := r.URL.Query().Get("group_name")
-	songName := r.URL.Query().Get("song_name")
-	releaseDate := r.URL.Query().Get("release_date")
-
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit <= 0 {
-		limit = 10
+	filter, err := parseSongFilter(r)
+	if err != nil {
+		respondFilterError(w, r, h.loggers, err)
+		return
 	}
 
-	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
+	facets, err := parseFacets(r.URL.Query().Get("facets"))
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid facets", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	filter := repository.SongFilter{
-		Group:       groupName,
-		Song:        songName,
-		ReleaseDate: releaseDate,
+	page := parsePageRequest(r)
+	page.Facets = facets
+
+	result, err := h.songService.GetSongs(ctx, filter, page)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch songs")
+		return
 	}
 
-	songs, err := h.songService.GetSongs(ctx, filter, limit, offset)
+	response := newSongListResponse(w, r, result, page.Limit)
+	response.Facets = result.Facets
+
+	etag, err := contentETag(response)
 	if err != nil {
-		h.loggers.ErrorLogger.Error("Failed to fetch songs", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to fetch songs")
+		respondError(w, r, h.loggers, err, "Failed to fetch songs")
 		return
 	}
 
-	h.loggers.InfoLogger.Info("Fetched songs successfully", slog.Int("count", len(songs)))
-	utils.RespondWithJSON(w, http.StatusOK, songs)
+	h.loggers.InfoLogger.Info("Fetched songs successfully", slog.Int("count", len(result.Songs)))
+	respondWithETag(w, r, etag, response)
+}
+
+// newSongListResponse wraps a page of songs in the list envelope and sets
+// the Link header pointing at the neighbouring pages.
+func newSongListResponse(w http.ResponseWriter, r *http.Request, result *service.SongPage, limit int) SongListResponse {
+	response := SongListResponse{
+		Data: make([]SongItem, 0, len(result.Songs)),
+		Page: PageInfo{Limit: limit, Total: result.Total},
+	}
+	for i := range result.Songs {
+		response.Data = append(response.Data, SongItem{Song: result.Songs[i], ETag: songETag(&result.Songs[i])})
+	}
+
+	var links []string
+	if result.NextCursor != "" {
+		response.Page.NextCursor = &result.NextCursor
+		links = append(links, `<`+cursorURL(r, result.NextCursor)+`>; rel="next"`)
+	}
+	if result.PrevCursor != "" {
+		response.Page.PrevCursor = &result.PrevCursor
+		links = append(links, `<`+cursorURL(r, result.PrevCursor)+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	return response
+}
+
+// cursorURL rebuilds the request URL pointing at another page, keeping the
+// caller's filters and limit.
+func cursorURL(r *http.Request, cursor string) string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// LyricsResponse is a page of a song's lyrics split into stanzas.
+// TotalVerses counts stanzas or lines depending on Unit; NextOffset is null
+// on the last page. Structure names the section of every stanza of the
+// song, e.g. "A B C B D B".
+type LyricsResponse struct {
+	Unit        lyrics.Unit     `json:"unit"`
+	Stanzas     []lyrics.Stanza `json:"stanzas"`
+	TotalVerses int             `json:"total_verses"`
+	NextOffset  *int            `json:"next_offset"`
+	Structure   string          `json:"structure"`
 }
 
 // GetSongLyricsPaginated godoc
 // @Summary Get paginated lyrics of a song
-// @Description Get song lyrics paginated by verses.
+// @Description Get song lyrics as stanzas (blocks separated by blank lines, labelled by [Chorus]-style markers). Pagination counts stanzas by default, or lines with unit=line. Each stanza names its section; repeated stanzas point at their first occurrence with repeat_of, and compact=true leaves out their lines.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param unit query string false "Pagination unit" Enums(stanza, line)
 // @Param limit query int false "Pagination limit"
 // @Param offset query int false "Pagination offset"
-// @Success 200 {array} string
-// @Failure 400 {object} utils.JSONError "Invalid song ID"
-// @Failure 500 {object} utils.JSONError "Failed to fetch lyrics"
+// @Param compact query bool false "Omit the lines of repeated stanzas (stanza unit only)"
+// @Param mask query bool false "Mask words from the explicit word lists"
+// @Success 200 {object} LyricsResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID or unit"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch lyrics"
 // @Router /songs/{id}/lyrics [get]
 func (h *SongHandler) GetSongLyricsPaginated(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -92,7 +192,13 @@ func (h *SongHandler) GetSongLyricsPaginated(w http.ResponseWriter, r *http.Requ
 	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		h.loggers.ErrorLogger.Error("Invalid song ID", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Invalid song ID")
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid song ID")
+		return
+	}
+
+	unit, err := parseLyricsUnit(r)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -106,15 +212,165 @@ func (h *SongHandler) GetSongLyricsPaginated(w http.ResponseWriter, r *http.Requ
 		offset = 0
 	}
 
-	lyrics, err := h.songService.GetSongLyricsPaginated(ctx, songID, limit, offset)
+	var opts service.LyricsOptions
+	if raw := r.URL.Query().Get("compact"); raw != "" {
+		opts.Compact, err = strconv.ParseBool(raw)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter compact must be a boolean")
+			return
+		}
+		if opts.Compact && unit != lyrics.UnitStanza {
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "compact is only supported with unit=stanza")
+			return
+		}
+	}
+	if raw := r.URL.Query().Get("mask"); raw != "" {
+		opts.Mask, err = strconv.ParseBool(raw)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter mask must be a boolean")
+			return
+		}
+	}
+
+	page, err := h.songService.GetSongLyricsPaginated(ctx, songID, unit, limit, offset, opts)
 	if err != nil {
-		h.loggers.ErrorLogger.Error("Failed to fetch lyrics", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to fetch lyrics")
+		respondError(w, r, h.loggers, err, "Failed to fetch lyrics")
 		return
 	}
 
+	response := LyricsResponse{Unit: unit, Stanzas: page.Stanzas, TotalVerses: page.Total, Structure: page.Structure}
+	if next := offset + limit; next < page.Total {
+		response.NextOffset = &next
+	}
+
 	h.loggers.InfoLogger.Info("Fetched song lyrics successfully", slog.Int("songID", songID))
-	utils.RespondWithJSON(w, http.StatusOK, lyrics)
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+type LyricsSearchResponse struct {
+	Query   string        `json:"query"`
+	Limit   int           `json:"limit"`
+	Matches []LyricsMatch `json:"matches"`
+}
+
+// LyricsMatch is a search hit together with the offset of the
+// GET /songs/{id}/lyrics page (for the requested limit and unit) that
+// contains it.
+type LyricsMatch struct {
+	lyrics.Match
+	Offset int `json:"offset"`
+}
+
+// SearchSongLyrics godoc
+// @Summary Search within a song's lyrics
+// @Description Find the verses, line numbers and character offsets where a term or phrase occurs. Matching ignores case and diacritics unless asked otherwise.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param q query string true "Term or phrase to search for"
+// @Param match_case query bool false "Match case exactly"
+// @Param match_diacritics query bool false "Match diacritics exactly"
+// @Param unit query string false "Pagination unit used to compute the offset of each match" Enums(stanza, line)
+// @Param limit query int false "Page size used to compute the offset of each match"
+// @Success 200 {object} LyricsSearchResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID, unit, flag or missing query"
+// @Failure 500 {object} utils.Problem "Failed to search lyrics"
+// @Router /songs/{id}/lyrics/search [get]
+func (h *SongHandler) SearchSongLyrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling SearchSongLyrics request")
+
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid song ID", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid song ID")
+		return
+	}
+
+	term := strings.TrimSpace(r.URL.Query().Get("q"))
+	if term == "" {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+
+	unit, err := parseLyricsUnit(r)
+	if err != nil {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	var matchCase, matchDiacritics bool
+	if raw := r.URL.Query().Get("match_case"); raw != "" {
+		matchCase, err = strconv.ParseBool(raw)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter match_case must be a boolean")
+			return
+		}
+	}
+	if raw := r.URL.Query().Get("match_diacritics"); raw != "" {
+		matchDiacritics, err = strconv.ParseBool(raw)
+		if err != nil {
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter match_diacritics must be a boolean")
+			return
+		}
+	}
+	opts := lyrics.FoldOptions{Case: !matchCase, Diacritics: !matchDiacritics}
+
+	found, err := h.songService.SearchSongLyrics(ctx, songID, term, opts)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to search lyrics")
+		return
+	}
+
+	response := LyricsSearchResponse{Query: term, Limit: limit, Matches: []LyricsMatch{}}
+	for _, m := range found {
+		position := m.Verse
+		if unit == lyrics.UnitLine {
+			position = m.Line - 1
+		}
+		response.Matches = append(response.Matches, LyricsMatch{Match: m, Offset: position / limit * limit})
+	}
+
+	h.loggers.InfoLogger.Info("Searched song lyrics successfully", slog.Int("songID", songID), slog.Int("matches", len(found)))
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// GetSong godoc
+// @Summary Get a song by ID
+// @Description Retrieve a single song with its details and lyrics. The ETag header carries the song's version; send it in If-None-Match to get 304 when unchanged, or in If-Match to guard writes.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} domain.Song
+// @Success 304 "Not modified"
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch song"
+// @Router /songs/{id} [get]
+func (h *SongHandler) GetSong(w http.ResponseWriter, r *http.Request) {
+	h.loggers.DebugLogger.Debug("Handling GetSong request")
+
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	song, err := h.songService.GetSongByID(r.Context(), songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch song")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Fetched song successfully", slog.Int("songID", songID))
+	w.Header().Set("Accept-Patch", acceptPatch())
+	respondWithETag(w, r, songETag(song), song)
 }
 
 // DeleteSong godoc
@@ -124,9 +380,13 @@ func (h *SongHandler) GetSongLyricsPaginated(w http.ResponseWriter, r *http.Requ
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the version being changed; required in strict mode"
 // @Success 200 {object} map[string]string "status and message"
-// @Failure 400 {object} utils.JSONError "Invalid song ID"
-// @Failure 500 {object} utils.JSONError "Failed to delete song"
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 412 {object} utils.Problem "Song was modified since the If-Match ETag"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to delete song"
 // @Router /songs/{id} [delete]
 func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -135,13 +395,17 @@ func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		h.loggers.ErrorLogger.Error("Invalid song ID", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Invalid song ID")
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid song ID")
 		return
 	}
 
-	if err := h.songService.DeleteSong(ctx, songID); err != nil {
-		h.loggers.ErrorLogger.Error("Failed to delete song", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to delete song")
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.songService.DeleteSong(ctx, songID, version); err != nil {
+		respondError(w, r, h.loggers, err, "Failed to delete song")
 		return
 	}
 
@@ -154,15 +418,20 @@ func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSong godoc
 // @Summary Update a song's details
-// @Description Update an existing song's details in the library and return the updated song data.
+// @Description Update an existing song's details in the library and return the updated song data. Without a language, a manual language override is kept and otherwise the language is detected from the lyrics.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
 // @Param song body domain.Song true "Updated song"
+// @Param If-Match header string false "ETag of the version being changed; required in strict mode"
 // @Success 200 {object} domain.Song "Updated song details"
-// @Failure 400 {object} utils.JSONError "Invalid song ID or payload"
-// @Failure 500 {object} utils.JSONError "Failed to update song"
+// @Failure 400 {object} utils.Problem "Invalid song ID or payload"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 412 {object} utils.Problem "Song was modified since the If-Match ETag"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 422 {object} utils.Problem "Invalid or unknown song fields"
+// @Failure 500 {object} utils.Problem "Failed to update song"
 // @Router /songs/{id} [put]
 func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -171,75 +440,160 @@ func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		h.loggers.ErrorLogger.Error("Invalid song ID", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Invalid song ID")
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid song ID")
 		return
 	}
 
-	var song domain.Song
-	if err := json.NewDecoder(r.Body).Decode(&song); err != nil {
-		h.loggers.ErrorLogger.Error("Invalid request payload", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Invalid request payload")
+	song, ok := h.decodeSong(w, r)
+	if !ok {
 		return
 	}
 
-	song.ID = songID
-
-	if err := h.songService.UpdateSong(ctx, song); err != nil {
-		h.loggers.ErrorLogger.Error("Failed to update song", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to update song")
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
+	song.ID = songID
+	song.Version = version
 
-	updatedSong, err := h.songService.GetSongByID(ctx, songID)
+	updatedSong, err := h.songService.UpdateSong(ctx, song)
 	if err != nil {
-		h.loggers.ErrorLogger.Error("Failed to retrieve updated song", utils.Err(err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to retrieve updated song")
+		respondError(w, r, h.loggers, err, "Failed to update song")
 		return
 	}
 
 	// Return the updated song as response
 	h.loggers.InfoLogger.Info("Updated song successfully", slog.Int("songID", songID))
+	w.Header().Set("ETag", songETag(updatedSong))
 	utils.RespondWithJSON(w, http.StatusOK, updatedSong)
 }
 
+// maxPatchSize bounds the size of song patch documents.
+const maxPatchSize = 1 << 20
+
+// PatchSong godoc
+// @Summary Partially update a song
+// @Description Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to the song as returned by GET /songs/{id}. Only group, song, release_date, text, link, tags and language may change, and only the changed columns are written. The patched song is validated as a whole.
+// @Tags songs
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param patch body object true "Merge patch object or array of JSON Patch operations"
+// @Param If-Match header string false "ETag of the version being changed; required in strict mode"
+// @Success 200 {object} domain.Song "Patched song"
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 409 {object} utils.Problem "A JSON Patch test operation failed"
+// @Failure 412 {object} utils.Problem "Song was modified since the If-Match ETag"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 413 {object} utils.Problem "Patch document is too large"
+// @Failure 415 {object} utils.Problem "Unsupported patch format"
+// @Failure 422 {object} utils.Problem "Invalid patch or patched song"
+// @Failure 500 {object} utils.Problem "Failed to patch song"
+// @Router /songs/{id} [patch]
+func (h *SongHandler) PatchSong(w http.ResponseWriter, r *http.Request) {
+	h.loggers.DebugLogger.Debug("Handling PatchSong request")
+
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, err := patch.ParseFormat(mediaType)
+	if err != nil {
+		w.Header().Set("Accept-Patch", acceptPatch())
+		utils.RespondWithErrorJSON(w, r, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) {
+			utils.RespondWithErrorJSON(w, r, http.StatusRequestEntityTooLarge, "Patch document is too large")
+			return
+		}
+		h.loggers.ErrorLogger.Error("Failed to read patch document", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	song, err := h.songService.PatchSong(r.Context(), songID, version, format, body)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to patch song")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Patched song successfully", slog.Int("songID", songID))
+	w.Header().Set("ETag", songETag(song))
+	utils.RespondWithJSON(w, http.StatusOK, song)
+}
+
+// acceptPatch lists the supported patch formats for the Accept-Patch header.
+func acceptPatch() string {
+	var formats []string
+	for _, f := range patch.Formats() {
+		formats = append(formats, string(f))
+	}
+	return strings.Join(formats, ", ")
+}
+
 // AddSong godoc
 // @Summary Add a new song
-// @Description Adds a new song to the library.
+// @Description Adds a new song to the library and returns it with its ID, linked from the Location header. A given language is kept as a manual override; otherwise it is detected from the lyrics.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param song body domain.Song true "New song to add"
-// @Success 201 {object} map[string]interface{} "status and message"
-// @Failure 400 {object} utils.JSONError "Invalid request payload"
-// @Failure 500 {object} utils.JSONError "Failed to add song"
+// @Param Idempotency-Key header string false "Unique key making retries safe: a retry with the same key and body gets the original response"
+// @Success 201 {object} domain.Song "Created song"
+// @Header 201 {string} Location "/songs/{id}"
+// @Header 201 {string} ETag "Version of the created song"
+// @Failure 400 {object} utils.Problem "Invalid request payload"
+// @Failure 409 {object} utils.Problem "Idempotency-Key reused for a different request, or still in use"
+// @Failure 422 {object} utils.Problem "Invalid or unknown song fields"
+// @Failure 500 {object} utils.Problem "Failed to add song"
 // @Router /songs [post]
 func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	h.loggers.DebugLogger.Debug("Handling AddSong request")
 
-	var song domain.Song
-	if err := json.NewDecoder(r.Body).Decode(&song); err != nil {
-		h.loggers.ErrorLogger.Error("Invalid request payload", slog.Any("error", err))
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Invalid request payload")
+	song, ok := h.decodeSong(w, r)
+	if !ok {
 		return
 	}
 
-	if song.Group == "" || song.Song == "" {
-		h.loggers.ErrorLogger.Error("Group and song fields are required")
-		utils.RespondWithErrorJSON(w, http.StatusBadRequest, "Group and song fields are required")
+	created, err := h.songService.AddSong(ctx, song)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to add song")
 		return
 	}
 
-	if err := h.songService.AddSong(ctx, song); err != nil {
-		h.loggers.ErrorLogger.Error("Failed to add song", slog.Any("error", err))
-		utils.RespondWithErrorJSON(w, http.StatusInternalServerError, "Failed to add song")
-		return
-	}
+	h.loggers.InfoLogger.Info("Added song successfully", slog.Int("songID", created.ID))
+	w.Header().Set("Location", fmt.Sprintf("/songs/%d", created.ID))
+	w.Header().Set("ETag", songETag(created))
+	utils.RespondWithJSON(w, http.StatusCreated, created)
+}
 
-	response := map[string]interface{}{
-		"status":  "success",
-		"message": "Song added successfully",
+// decodeSong reads the song in the request body, answering the request
+// like decodeJSON when it cannot.
+func (h *SongHandler) decodeSong(w http.ResponseWriter, r *http.Request) (domain.Song, bool) {
+	song, err := service.DecodeSong(r.Body)
+	return song, decoded(w, r, h.loggers, err)
+}
+
+func (h *SongHandler) songID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid song ID", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid song ID")
+		return 0, false
 	}
-	h.loggers.InfoLogger.Info("Added song successfully", slog.String("group", song.Group), slog.String("song", song.Song))
-	utils.RespondWithJSON(w, http.StatusCreated, response)
+	return songID, true
 }
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+const (
+	// maxIdempotencyKeyLength matches the key column.
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the requests that may carry a key, as
+	// their bodies are read up front to fingerprint them.
+	maxIdempotentBodySize = 1 << 20
+	// maxIdempotentResponseSize bounds the responses stored for replay;
+	// larger ones are passed through without being stored.
+	maxIdempotentResponseSize = 1 << 20
+)
+
+// replayedHeaders are the response headers stored for replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Accept-Patch"}
+
+// fingerprintedHeaders are the request headers that change what a request
+// does, so a key reused with different values is a different request.
+var fingerprintedHeaders = []string{"Content-Type", "If-Match"}
+
+// Idempotency makes POST requests sent with an Idempotency-Key header safe
+// to retry. The first request with a key runs and its response is stored;
+// a retry with the same method, target and body gets the stored response
+// back, marked with Idempotent-Replayed, while one with a different request
+// or one arriving while the first still runs gets 409. Server errors, and
+// responses too large to store, are not stored, so a retry after one runs
+// the request again.
+func Idempotency(idempotencyService service.IdempotencyService, loggers *logger.Loggers) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+
+			if len(key) > maxIdempotencyKeyLength {
+				utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+			if err != nil {
+				loggers.ErrorLogger.Error("Failed to read request body", utils.Err(err))
+				utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			if len(body) > maxIdempotentBodySize {
+				utils.RespondWithErrorJSON(w, r, http.StatusRequestEntityTooLarge, "Requests with an Idempotency-Key are limited to 1 MiB")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var headers []string
+			for _, name := range fingerprintedHeaders {
+				headers = append(headers, name+": "+r.Header.Get(name))
+			}
+			stored, err := idempotencyService.Begin(ctx, key, service.Fingerprint(r.Method, r.URL.RequestURI(), headers, body))
+			if err != nil {
+				respondError(w, r, loggers, err, "Failed to check idempotency key")
+				return
+			}
+			if stored != nil {
+				for name, value := range stored.Header {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			// The outcome is recorded even if the client has gone away, as
+			// that is when it will retry.
+			ctx = context.WithoutCancel(ctx)
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := idempotencyService.Release(ctx, key); err != nil {
+					loggers.ErrorLogger.Error("Failed to release idempotency key", slog.String("key", key), utils.Err(err))
+				}
+			}()
+
+			holdCtx, stopHold := context.WithCancel(ctx)
+			defer stopHold()
+			go idempotencyService.Hold(holdCtx, key)
+			next.ServeHTTP(rec, r)
+			stopHold()
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			if rec.overflow {
+				loggers.InfoLogger.Info("Idempotent response too large to store", slog.String("key", key))
+				return
+			}
+
+			response := repository.IdempotentResponse{Status: rec.status, Header: make(map[string]string), Body: rec.body.Bytes()}
+			for _, name := range replayedHeaders {
+				if value := rec.Header().Get(name); value != "" {
+					response.Header[name] = value
+				}
+			}
+			// Releasing the key now could run a request that succeeded a
+			// second time, so it is left for a retry to take over once stale.
+			completed = true
+			if err := idempotencyService.Complete(ctx, key, response); err != nil {
+				loggers.ErrorLogger.Error("Failed to store idempotent response", slog.String("key", key), utils.Err(err))
+			}
+		})
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and of its body up to maxIdempotentResponseSize, noting overflow
+// beyond it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxIdempotentResponseSize {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIdempotencyService keeps keys in memory.
+type fakeIdempotencyService struct {
+	fingerprints map[string]string
+	responses    map[string]repository.IdempotentResponse
+}
+
+func newFakeIdempotencyService() *fakeIdempotencyService {
+	return &fakeIdempotencyService{fingerprints: map[string]string{}, responses: map[string]repository.IdempotentResponse{}}
+}
+
+func (s *fakeIdempotencyService) Begin(ctx context.Context, key, fingerprint string) (*repository.IdempotentResponse, error) {
+	stored, ok := s.fingerprints[key]
+	switch {
+	case !ok:
+		s.fingerprints[key] = fingerprint
+		return nil, nil
+	case stored != fingerprint:
+		return nil, service.ErrIdempotencyKeyReused
+	}
+	response, ok := s.responses[key]
+	if !ok {
+		return nil, service.ErrIdempotencyKeyInUse
+	}
+	return &response, nil
+}
+
+func (s *fakeIdempotencyService) Hold(ctx context.Context, key string) {}
+
+func (s *fakeIdempotencyService) Complete(ctx context.Context, key string, response repository.IdempotentResponse) error {
+	s.responses[key] = response
+	return nil
+}
+
+func (s *fakeIdempotencyService) Release(ctx context.Context, key string) error {
+	delete(s.fingerprints, key)
+	return nil
+}
+
+func (s *fakeIdempotencyService) RunPurger(ctx context.Context, interval time.Duration) {}
+
+// countingHandler creates a song per request, failing with status fail
+// instead while it is set.
+type countingHandler struct {
+	calls int
+	fail  int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	if h.fail != 0 {
+		http.Error(w, "failed", h.fail)
+		return
+	}
+	w.Header().Set("Location", "/songs/7")
+	w.Header().Set("X-Request-Only", "yes")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"id":7}`))
+}
+
+func idempotentRequest(method, key, body string) *http.Request {
+	r := httptest.NewRequest(method, "/songs", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	return r
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyService(), newTestLoggers(t))(next)
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, idempotentRequest(http.MethodPost, "k1", `{"song":"a"}`))
+	retry := httptest.NewRecorder()
+	h.ServeHTTP(retry, idempotentRequest(http.MethodPost, "k1", `{"song":"a"}`))
+
+	if next.calls != 1 {
+		t.Fatalf("handler ran %d times, want once", next.calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %q, want %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("only the retry must be marked Idempotent-Replayed")
+	}
+	if retry.Header().Get("Location") != "/songs/7" || retry.Header().Get("X-Request-Only") != "" {
+		t.Errorf("retry headers = %v, want only the replayed ones", retry.Header())
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyService(), newTestLoggers(t))(next)
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest(http.MethodPost, "k1", `{"song":"a"}`))
+
+	other := idempotentRequest(http.MethodPost, "k1", `{"song":"a"}`)
+	other.Header.Set("If-Match", `"2"`)
+	for name, r := range map[string]*http.Request{
+		"body":     idempotentRequest(http.MethodPost, "k1", `{"song":"b"}`),
+		"If-Match": other,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusConflict {
+			t.Errorf("different %s: status = %d, want %d", name, w.Code, http.StatusConflict)
+		}
+	}
+	if next.calls != 1 {
+		t.Errorf("handler ran %d times, want once", next.calls)
+	}
+}
+
+func TestIdempotencyRunsAgainAfterServerError(t *testing.T) {
+	next := &countingHandler{fail: http.StatusInternalServerError}
+	h := Idempotency(newFakeIdempotencyService(), newTestLoggers(t))(next)
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest(http.MethodPost, "k1", `{}`))
+	next.fail = 0
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest(http.MethodPost, "k1", `{}`))
+
+	if next.calls != 2 || w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after a server error = %d after %d calls, want a fresh 201", w.Code, next.calls)
+	}
+}
+
+func TestIdempotencyPassesThrough(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyService(), newTestLoggers(t))(next)
+
+	for _, r := range []*http.Request{
+		idempotentRequest(http.MethodPost, "", `{}`),
+		idempotentRequest(http.MethodPost, "", `{}`),
+		idempotentRequest(http.MethodPut, "k1", `{}`),
+		idempotentRequest(http.MethodPut, "k1", `{}`),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if next.calls != 4 {
+		t.Errorf("handler ran %d times, want 4", next.calls)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest(http.MethodPost, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`))
+	if w.Code != http.StatusBadRequest || next.calls != 4 {
+		t.Errorf("overlong key = %d, want %d without running the handler", w.Code, http.StatusBadRequest)
+	}
+}
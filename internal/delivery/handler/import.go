@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportRowResponse is one line of an import report. ID is the created,
+// updated or duplicate song; it is left out of dry runs.
+type ImportRowResponse struct {
+	Line   int                     `json:"line"`
+	Status repository.ImportStatus `json:"status"`
+	ID     int                     `json:"id,omitempty"`
+	Reason string                  `json:"reason,omitempty"`
+	Errors []utils.InvalidField    `json:"errors,omitempty"`
+}
+
+// ImportSummaryResponse is the last line of an import report. Error is set
+// if the import stopped early; the rows reported before it were written.
+type ImportSummaryResponse struct {
+	Summary service.ImportSummary `json:"summary"`
+	DryRun  bool                  `json:"dry_run"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// ImportSongs godoc
+// @Summary Import songs in bulk
+// @Description Stream songs as CSV with a header row or as NDJSON, one object per line in the shape accepted by POST /songs. CSV columns are named after the song fields (group, song, release_date, text, link, tags, language) unless renamed with map; tags are separated by semicolons. Songs are validated and written in batches of 100, each in its own transaction, so rows reported before a failure stay written. A song whose artist and title already exist, compared case-insensitively, is skipped in insert mode and replaced in upsert mode. The response streams one NDJSON line per record, followed by a summary line.
+// @Tags songs
+// @Accept text/csv,application/x-ndjson
+// @Produce application/x-ndjson
+// @Param body body string true "CSV or NDJSON songs"
+// @Param mode query string false "What to do with existing songs" Enums(insert, upsert) default(insert)
+// @Param dry_run query bool false "Validate and report without writing anything"
+// @Param map query string false "CSV column mapping as header:field pairs separated by commas, e.g. Artist:group,Title:song; map a column to - to ignore it"
+// @Success 200 {object} ImportRowResponse "One line per record, then an ImportSummaryResponse line"
+// @Failure 400 {object} utils.Problem "Invalid mode, dry_run or map"
+// @Failure 415 {object} utils.Problem "Unsupported content type"
+// @Failure 422 {object} utils.Problem "Invalid CSV header"
+// @Failure 500 {object} utils.Problem "Failed to import songs"
+// @Router /songs/import [post]
+func (h *SongHandler) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling ImportSongs request")
+
+	opts, err := parseImportOptions(r)
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid import options", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source, ok := h.importSource(w, r)
+	if !ok {
+		return
+	}
+
+	// An import streams for as long as its body does, so the server's read
+	// and write timeouts are lifted for it.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	encoder := json.NewEncoder(w)
+	started := false
+	summary, err := h.songService.ImportSongs(ctx, source, opts, func(rows []service.ImportRowResult) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, row := range rows {
+			if err := encoder.Encode(h.importRowResponse(row, opts.DryRun)); err != nil {
+				return err
+			}
+		}
+		rc.Flush()
+		return nil
+	})
+	if err != nil && !started {
+		respondError(w, r, h.loggers, err, "Failed to import songs")
+		return
+	}
+	if !started {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	trailer := ImportSummaryResponse{Summary: summary, DryRun: opts.DryRun}
+	if err != nil {
+		trailer.Error = newProblem(h.loggers, err, "Import stopped by an internal error").Detail
+	}
+	encoder.Encode(trailer)
+
+	h.loggers.InfoLogger.Info("Imported songs", slog.Any("summary", summary), slog.Bool("dryRun", opts.DryRun))
+}
+
+func (h *SongHandler) importRowResponse(row service.ImportRowResult, dryRun bool) ImportRowResponse {
+	resp := ImportRowResponse{Line: row.Line, Status: row.Status}
+	if row.Err != nil {
+		problem := newProblem(h.loggers, row.Err, "Failed to store song")
+		resp.Reason, resp.Errors = problem.Detail, problem.Errors
+		return resp
+	}
+	if !dryRun {
+		resp.ID = row.Song.ID
+	}
+	return resp
+}
+
+func parseImportOptions(r *http.Request) (service.ImportOptions, error) {
+	var opts service.ImportOptions
+	q := r.URL.Query()
+
+	switch mode := q.Get("mode"); mode {
+	case "", "insert":
+	case "upsert":
+		opts.Upsert = true
+	default:
+		return opts, fmt.Errorf("invalid mode %q", mode)
+	}
+
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid dry_run %q", v)
+		}
+		opts.DryRun = dryRun
+	}
+	return opts, nil
+}
+
+// importSource picks the reader for the request body by its content type.
+func (h *SongHandler) importSource(w http.ResponseWriter, r *http.Request) (service.SongSource, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl":
+		return service.NewNDJSONSource(r.Body), true
+	case "text/csv":
+		mapping, err := parseCSVMapping(r)
+		if err != nil {
+			h.loggers.ErrorLogger.Error("Invalid CSV mapping", utils.Err(err))
+			utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		source, err := service.NewCSVSource(r.Body, mapping)
+		if err != nil {
+			respondError(w, r, h.loggers, err, "Failed to read CSV header")
+			return nil, false
+		}
+		return source, true
+	default:
+		utils.RespondWithErrorJSON(w, r, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return nil, false
+	}
+}
+
+// parseCSVMapping reads the map query parameters, each a comma-separated
+// list of header:field pairs.
+func parseCSVMapping(r *http.Request) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, v := range r.URL.Query()["map"] {
+		for _, pair := range strings.Split(v, ",") {
+			header, field, ok := strings.Cut(pair, ":")
+			header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+			if !ok || header == "" || field == "" {
+				return nil, fmt.Errorf("invalid map entry %q, want header:field", pair)
+			}
+			mapping[header] = field
+		}
+	}
+	return mapping, nil
+}
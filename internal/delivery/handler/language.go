@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log/slog"
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+type LanguageRequest struct {
+	Language string `json:"language" example:"de"`
+}
+
+// SetSongLanguage godoc
+// @Summary Override a song's language
+// @Description Set the song's language by hand. Manual languages are kept when the lyrics change and are skipped by re-detection.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param language body LanguageRequest true "ISO 639-1 language code"
+// @Success 200 {object} domain.Song
+// @Failure 400 {object} utils.Problem "Invalid song ID or payload"
+// @Failure 422 {object} utils.Problem "Invalid language"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to set song language"
+// @Router /songs/{id}/language [put]
+func (h *SongHandler) SetSongLanguage(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	var req LanguageRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+	if req.Language == "" {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	h.setSongLanguage(w, r, songID, req.Language)
+}
+
+// ClearSongLanguage godoc
+// @Summary Remove a song's language override
+// @Description Drop the manual language and detect it from the lyrics again.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} domain.Song
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to set song language"
+// @Router /songs/{id}/language [delete]
+func (h *SongHandler) ClearSongLanguage(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	h.setSongLanguage(w, r, songID, "")
+}
+
+func (h *SongHandler) setSongLanguage(w http.ResponseWriter, r *http.Request, songID int, language string) {
+	song, err := h.songService.SetSongLanguage(r.Context(), songID, language)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to set song language")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Set song language successfully", slog.Int("songID", songID), slog.String("language", song.Language))
+	utils.RespondWithJSON(w, http.StatusOK, song)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultFacetLimit = 10
+	maxFacetLimit     = 100
+)
+
+// parseSongFilter reads the song listing filters shared by the endpoints
+// that select songs from the query string. An invalid search expression is
+// reported as a validation error; any other invalid filter as a plain one.
+func parseSongFilter(r *http.Request) (repository.SongFilter, error) {
+	q := r.URL.Query()
+
+	filter := repository.SongFilter{
+		Artist:      q.Get("artist"),
+		Group:       q.Get("group_name"),
+		Song:        q.Get("song_name"),
+		ReleaseDate: q.Get("release_date"),
+		Tag:         q.Get("tag"),
+		Language:    strings.ToLower(q.Get("lang")),
+		Query:       q.Get("q"),
+	}
+
+	if filter.Query != "" {
+		if err := repository.ValidateSongQuery(filter.Query); err != nil {
+			return filter, domain.Validation(err)
+		}
+	}
+
+	if v := q.Get("decade"); v != "" {
+		decade, err := strconv.Atoi(v)
+		if err != nil || decade%10 != 0 {
+			return filter, fmt.Errorf("invalid decade %q", v)
+		}
+		filter.Decade = decade
+	}
+
+	if v := q.Get("has_lyrics"); v != "" {
+		hasLyrics, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid has_lyrics %q", v)
+		}
+		filter.HasLyrics = &hasLyrics
+	}
+
+	if v := q.Get("clean"); v != "" {
+		clean, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid clean %q", v)
+		}
+		filter.Clean = clean
+	}
+
+	return filter, nil
+}
+
+// respondFilterError answers a parseSongFilter failure: 422 with the
+// position of the problem for an invalid search expression, 400 otherwise.
+func respondFilterError(w http.ResponseWriter, r *http.Request, loggers *logger.Loggers, err error) {
+	if errors.Is(err, domain.ErrValidation) {
+		respondError(w, r, loggers, err, "Invalid search expression")
+		return
+	}
+	loggers.ErrorLogger.Error("Invalid song filter", utils.Err(err))
+	utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, err.Error())
+}
+
+// parseFacets parses a facet list such as "artist:5,decade,tag". "genre" is
+// accepted as an alias for the tag facet.
+func parseFacets(raw string) ([]repository.FacetRequest, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var facets []repository.FacetRequest
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		name, limitStr, hasLimit := strings.Cut(strings.TrimSpace(item), ":")
+		if name == "genre" {
+			name = repository.FacetTag
+		}
+		if !repository.IsFacet(name) {
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		limit := defaultFacetLimit
+		if hasLimit {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 || limit > maxFacetLimit {
+				return nil, fmt.Errorf("invalid limit for facet %q", name)
+			}
+		}
+
+		facets = append(facets, repository.FacetRequest{Name: name, Limit: limit})
+	}
+
+	return facets, nil
+}
+
+// parsePageRequest reads limit, cursor, offset and include_total from the
+// query string.
+func parsePageRequest(r *http.Request) service.PageRequest {
+	q := r.URL.Query()
+
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(q.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	includeTotal, _ := strconv.ParseBool(q.Get("include_total"))
+
+	return service.PageRequest{
+		Limit:        limit,
+		Offset:       offset,
+		Cursor:       q.Get("cursor"),
+		IncludeTotal: includeTotal,
+	}
+}
+
+// parseLyricsUnit reads the lyrics pagination unit, defaulting to stanzas.
+func parseLyricsUnit(r *http.Request) (lyrics.Unit, error) {
+	switch unit := lyrics.Unit(r.URL.Query().Get("unit")); unit {
+	case "":
+		return lyrics.UnitStanza, nil
+	case lyrics.UnitStanza, lyrics.UnitLine:
+		return unit, nil
+	default:
+		return "", fmt.Errorf("invalid unit %q", unit)
+	}
+}
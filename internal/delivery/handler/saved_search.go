@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"music-service/internal/repository"
+	"music-service/internal/service"
+	"music-service/pkg/logger"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService service.SavedSearchService
+	loggers            *logger.Loggers
+}
+
+func NewSavedSearchHandler(savedSearchService service.SavedSearchService, loggers *logger.Loggers) *SavedSearchHandler {
+	return &SavedSearchHandler{savedSearchService: savedSearchService, loggers: loggers}
+}
+
+// NewSongListResponse is the envelope returned for songs added since a
+// saved search was last checked.
+type NewSongListResponse struct {
+	SongListResponse
+	Since time.Time `json:"since"`
+	AsOf  time.Time `json:"as_of"`
+}
+
+type MarkCheckedRequest struct {
+	CheckedAt *time.Time `json:"checked_at"`
+}
+
+// CreateSavedSearch godoc
+// @Summary Save a search
+// @Description Save a named song filter and search query. When webhook_url is set, songs that newly match are posted to it.
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Param search body repository.SavedSearch true "Saved search"
+// @Success 201 {object} repository.SavedSearch
+// @Header 201 {string} Location "/saved-searches/{id}"
+// @Failure 400 {object} utils.Problem "Invalid request payload"
+// @Failure 422 {object} utils.Problem "Invalid saved search"
+// @Failure 500 {object} utils.Problem "Failed to create saved search"
+// @Router /saved-searches [post]
+func (h *SavedSearchHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling CreateSavedSearch request")
+
+	var search repository.SavedSearch
+	if !decodeJSON(w, r, h.loggers, &search) {
+		return
+	}
+
+	created, err := h.savedSearchService.CreateSavedSearch(ctx, search)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to create saved search")
+		return
+	}
+
+	h.loggers.InfoLogger.Info("Created saved search successfully", slog.Int("id", created.ID))
+	w.Header().Set("Location", fmt.Sprintf("/saved-searches/%d", created.ID))
+	utils.RespondWithJSON(w, http.StatusCreated, created)
+}
+
+// GetSavedSearches godoc
+// @Summary List saved searches
+// @Tags saved-searches
+// @Produce json
+// @Success 200 {array} repository.SavedSearch
+// @Failure 500 {object} utils.Problem "Failed to fetch saved searches"
+// @Router /saved-searches [get]
+func (h *SavedSearchHandler) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.savedSearchService.GetSavedSearches(r.Context())
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch saved searches")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, searches)
+}
+
+// GetSavedSearch godoc
+// @Summary Get a saved search
+// @Tags saved-searches
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} repository.SavedSearch
+// @Failure 400 {object} utils.Problem "Invalid saved search ID"
+// @Failure 404 {object} utils.Problem "Saved search not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch saved search"
+// @Router /saved-searches/{id} [get]
+func (h *SavedSearchHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	search, err := h.savedSearchService.GetSavedSearchByID(r.Context(), id)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch saved search")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, search)
+}
+
+// DeleteSavedSearch godoc
+// @Summary Delete a saved search
+// @Tags saved-searches
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} map[string]string "status and message"
+// @Failure 400 {object} utils.Problem "Invalid saved search ID"
+// @Failure 404 {object} utils.Problem "Saved search not found"
+// @Failure 500 {object} utils.Problem "Failed to delete saved search"
+// @Router /saved-searches/{id} [delete]
+func (h *SavedSearchHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(r.Context(), id); err != nil {
+		respondError(w, r, h.loggers, err, "Failed to delete saved search")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"status":  "success",
+		"message": "Saved search deleted successfully",
+	})
+}
+
+// GetSavedSearchResults godoc
+// @Summary Run a saved search
+// @Description Return the songs currently matching a saved search, paginated like GET /songs.
+// @Tags saved-searches
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Param limit query int false "Pagination limit"
+// @Param cursor query string false "Pagination cursor from a previous response"
+// @Param include_total query bool false "Include the total number of matching songs"
+// @Success 200 {object} SongListResponse
+// @Failure 400 {object} utils.Problem "Invalid saved search ID"
+// @Failure 422 {object} utils.Problem "Invalid cursor"
+// @Failure 404 {object} utils.Problem "Saved search not found"
+// @Failure 500 {object} utils.Problem "Failed to run saved search"
+// @Router /saved-searches/{id}/results [get]
+func (h *SavedSearchHandler) GetSavedSearchResults(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	page := parsePageRequest(r)
+	result, err := h.savedSearchService.GetSavedSearchResults(r.Context(), id, page)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to run saved search")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, newSongListResponse(w, r, result, page.Limit))
+}
+
+// GetNewSavedSearchResults godoc
+// @Summary Songs new since a saved search was last checked
+// @Description Return matching songs added after the saved search's last_checked_at. Reading this view does not move last_checked_at; mark the search as checked with the returned as_of once the results have been seen.
+// @Tags saved-searches
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Param limit query int false "Pagination limit"
+// @Param cursor query string false "Pagination cursor from a previous response"
+// @Success 200 {object} NewSongListResponse
+// @Failure 400 {object} utils.Problem "Invalid saved search ID"
+// @Failure 422 {object} utils.Problem "Invalid cursor"
+// @Failure 404 {object} utils.Problem "Saved search not found"
+// @Failure 500 {object} utils.Problem "Failed to run saved search"
+// @Router /saved-searches/{id}/new [get]
+func (h *SavedSearchHandler) GetNewSavedSearchResults(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	page := parsePageRequest(r)
+	result, err := h.savedSearchService.GetNewSavedSearchResults(r.Context(), id, page)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to run saved search")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewSongListResponse{
+		SongListResponse: newSongListResponse(w, r, result.SongPage, page.Limit),
+		Since:            result.Since,
+		AsOf:             result.AsOf,
+	})
+}
+
+// MarkSavedSearchChecked godoc
+// @Summary Mark a saved search as checked
+// @Description Move last_checked_at to checked_at (usually the as_of of the new-songs view), or to now when omitted.
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Param body body MarkCheckedRequest false "Check time"
+// @Success 200 {object} repository.SavedSearch
+// @Failure 400 {object} utils.Problem "Invalid saved search ID or payload"
+// @Failure 422 {object} utils.Problem "Unknown or mistyped field"
+// @Failure 404 {object} utils.Problem "Saved search not found"
+// @Failure 500 {object} utils.Problem "Failed to mark saved search as checked"
+// @Router /saved-searches/{id}/check [post]
+func (h *SavedSearchHandler) MarkSavedSearchChecked(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	var req MarkCheckedRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	checkedAt := time.Now()
+	if req.CheckedAt != nil {
+		checkedAt = *req.CheckedAt
+	}
+
+	if err := h.savedSearchService.MarkSavedSearchChecked(ctx, id, checkedAt); err != nil {
+		respondError(w, r, h.loggers, err, "Failed to mark saved search as checked")
+		return
+	}
+
+	search, err := h.savedSearchService.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch saved search")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, search)
+}
+
+func (h *SavedSearchHandler) savedSearchID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.loggers.ErrorLogger.Error("Invalid saved search ID", utils.Err(err))
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid saved search ID")
+		return 0, false
+	}
+	return id, true
+}
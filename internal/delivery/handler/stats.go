@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"music-service/internal/service"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultTopWords = 20
+
+// GetLyricsStats godoc
+// @Summary Lyrics statistics for a song
+// @Description Word, line and stanza counts, unique-word and repetition ratios, average line length and the most frequent words without stop words.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param top query int false "Number of top words" default(20)
+// @Success 200 {object} lyrics.Stats
+// @Failure 400 {object} utils.Problem "Invalid song ID or top"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to compute lyrics stats"
+// @Router /songs/{id}/lyrics/stats [get]
+func (h *SongHandler) GetLyricsStats(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	top, ok := h.topWords(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.songService.GetLyricsStats(r.Context(), songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to compute lyrics stats")
+		return
+	}
+
+	result := *stats
+	result.TopWords = result.TopWords[:min(top, len(result.TopWords))]
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// GetArtistVocabulary godoc
+// @Summary Vocabulary of an artist
+// @Description Lyrics statistics aggregated over every song by the artist (matched case-insensitively).
+// @Tags lyrics
+// @Produce json
+// @Param name path string true "Artist name"
+// @Param top query int false "Number of top words" default(20)
+// @Success 200 {object} service.ArtistVocabulary
+// @Failure 400 {object} utils.Problem "Invalid top"
+// @Failure 404 {object} utils.Problem "Artist not found"
+// @Failure 500 {object} utils.Problem "Failed to compute artist vocabulary"
+// @Router /artists/{name}/vocabulary [get]
+func (h *SongHandler) GetArtistVocabulary(w http.ResponseWriter, r *http.Request) {
+	artist := chi.URLParam(r, "name")
+
+	top, ok := h.topWords(w, r)
+	if !ok {
+		return
+	}
+
+	vocabulary, err := h.songService.GetArtistVocabulary(r.Context(), artist)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to compute artist vocabulary")
+		return
+	}
+
+	if vocabulary.Songs == 0 {
+		utils.RespondWithErrorJSON(w, r, http.StatusNotFound, "Artist not found")
+		return
+	}
+
+	result := *vocabulary
+	result.TopWords = result.TopWords[:min(top, len(result.TopWords))]
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *SongHandler) topWords(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("top")
+	if raw == "" {
+		return defaultTopWords, true
+	}
+
+	top, err := strconv.Atoi(raw)
+	if err != nil || top < 0 || top > service.MaxTopWords {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Query parameter top must be between 0 and "+strconv.Itoa(service.MaxTopWords))
+		return 0, false
+	}
+	return top, true
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"music-service/pkg/utils"
+	"net/http"
+)
+
+// GetLyricsStructure godoc
+// @Summary Repeated sections of a song
+// @Description Group near-identical stanzas into sections (A, B, ...), mark chorus and refrain candidates, and list lines repeated across sections.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} lyrics.Structure
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to detect lyrics structure"
+// @Router /songs/{id}/lyrics/structure [get]
+func (h *SongHandler) GetLyricsStructure(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	structure, err := h.songService.GetLyricsStructure(r.Context(), songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to detect lyrics structure")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, structure)
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"music-service/pkg/lrc"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxLRCSize bounds the size of uploaded LRC documents.
+const maxLRCSize = 1 << 20
+
+// maxSyncedPosition bounds the playback positions synced lyrics are looked
+// up at.
+const maxSyncedPosition = 24 * time.Hour
+
+type SyncedWordResponse struct {
+	Time float64 `json:"time"`
+	Text string  `json:"text"`
+}
+
+type SyncedLineResponse struct {
+	Index int                  `json:"index"`
+	Time  float64              `json:"time"`
+	Text  string               `json:"text"`
+	Words []SyncedWordResponse `json:"words,omitempty"`
+}
+
+type SyncedLyricsResponse struct {
+	Lines []SyncedLineResponse `json:"lines"`
+}
+
+type SyncedPositionResponse struct {
+	Time    float64             `json:"time"`
+	Current *SyncedLineResponse `json:"current"`
+	Next    *SyncedLineResponse `json:"next"`
+}
+
+func newSyncedLineResponse(index int, line lrc.Line) SyncedLineResponse {
+	resp := SyncedLineResponse{Index: index, Time: line.Time.Seconds(), Text: line.Text}
+	for _, word := range line.Words {
+		resp.Words = append(resp.Words, SyncedWordResponse{Time: word.Time.Seconds(), Text: word.Text})
+	}
+	return resp
+}
+
+// UploadSyncedLyrics godoc
+// @Summary Upload synced lyrics
+// @Description Replace a song's time-synced lyrics with an LRC document. Enhanced LRC word tags (<mm:ss.xx>) are kept. Timestamps must not go backwards.
+// @Tags lyrics
+// @Accept plain
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param lrc body string true "LRC document"
+// @Success 200 {object} SyncedLyricsResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 422 {object} utils.Problem "Invalid LRC document"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to save synced lyrics"
+// @Router /songs/{id}/lyrics/lrc [put]
+func (h *SongHandler) UploadSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling UploadSyncedLyrics request")
+
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	parsed, err := h.songService.ImportSyncedLyrics(ctx, songID, http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) {
+			utils.RespondWithErrorJSON(w, r, http.StatusRequestEntityTooLarge, "LRC document is too large")
+			return
+		}
+		respondError(w, r, h.loggers, err, "Failed to save synced lyrics")
+		return
+	}
+
+	response := SyncedLyricsResponse{Lines: []SyncedLineResponse{}}
+	for i, line := range parsed.Lines {
+		response.Lines = append(response.Lines, newSyncedLineResponse(i, line))
+	}
+
+	h.loggers.InfoLogger.Info("Uploaded synced lyrics successfully", slog.Int("songID", songID))
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// DownloadSyncedLyrics godoc
+// @Summary Download synced lyrics
+// @Description Download a song's time-synced lyrics as an LRC file.
+// @Tags lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "LRC document"
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Song or synced lyrics not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch synced lyrics"
+// @Router /songs/{id}/lyrics/lrc [get]
+func (h *SongHandler) DownloadSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.loggers.DebugLogger.Debug("Handling DownloadSyncedLyrics request")
+
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	synced, err := h.songService.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch synced lyrics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="song-%d.lrc"`, songID))
+	if err := lrc.Write(w, synced); err != nil {
+		h.loggers.ErrorLogger.Error("Failed to write LRC document", utils.Err(err))
+	}
+}
+
+// DeleteSyncedLyrics godoc
+// @Summary Delete synced lyrics
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} map[string]string "status and message"
+// @Failure 400 {object} utils.Problem "Invalid song ID"
+// @Failure 404 {object} utils.Problem "Synced lyrics not found"
+// @Failure 500 {object} utils.Problem "Failed to delete synced lyrics"
+// @Router /songs/{id}/lyrics/lrc [delete]
+func (h *SongHandler) DeleteSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.songService.DeleteSyncedLyrics(r.Context(), songID); err != nil {
+		respondError(w, r, h.loggers, err, "Failed to delete synced lyrics")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"status":  "success",
+		"message": "Synced lyrics deleted successfully",
+	})
+}
+
+// GetSyncedLineAt godoc
+// @Summary Synced lyrics line at a playback position
+// @Description Return the line being sung at playback position t (in seconds) and the line after it.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param t query number true "Playback position in seconds"
+// @Success 200 {object} SyncedPositionResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID or position"
+// @Failure 404 {object} utils.Problem "Song or synced lyrics not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch synced lyrics"
+// @Router /songs/{id}/lyrics/at [get]
+func (h *SongHandler) GetSyncedLineAt(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	seconds, err := strconv.ParseFloat(r.URL.Query().Get("t"), 64)
+	if err != nil || math.IsNaN(seconds) || seconds < 0 || seconds > maxSyncedPosition.Seconds() {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, fmt.Sprintf("Query parameter t must be a number of seconds between 0 and %.0f", maxSyncedPosition.Seconds()))
+		return
+	}
+
+	pos, err := h.songService.GetSyncedLineAt(r.Context(), songID, time.Duration(seconds*float64(time.Second)))
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch synced lyrics")
+		return
+	}
+
+	response := SyncedPositionResponse{Time: seconds}
+	if pos.Current != nil {
+		current := newSyncedLineResponse(pos.CurrentIndex, *pos.Current)
+		response.Current = &current
+	}
+	if pos.Next != nil {
+		next := newSyncedLineResponse(pos.CurrentIndex+1, *pos.Next)
+		response.Next = &next
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
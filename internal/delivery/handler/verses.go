@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// VerseRequest is the body of verse replace and insert requests. Position
+// is where an inserted verse goes (default: at the end); a nil Label keeps
+// a replaced verse's label.
+type VerseRequest struct {
+	Position *int     `json:"position,omitempty"`
+	Label    *string  `json:"label,omitempty"`
+	Lines    []string `json:"lines"`
+}
+
+// LineRequest is the body of line replace and insert requests. Position is
+// where an inserted line goes (default: at the end of the verse).
+type LineRequest struct {
+	Position *int   `json:"position,omitempty"`
+	Text     string `json:"text"`
+}
+
+type MoveVerseRequest struct {
+	To int `json:"to"`
+}
+
+// LineResponse is a single lyric line. Hash is the content hash of the
+// line's stanza, which edits to the line must send in If-Match.
+type LineResponse struct {
+	Verse int    `json:"verse"`
+	Line  int    `json:"line"`
+	Text  string `json:"text"`
+	Hash  string `json:"hash"`
+}
+
+// VersesResponse holds a song's stanzas after an edit, each with the hash
+// to send in If-Match on the next edit.
+type VersesResponse struct {
+	Stanzas []lyrics.Stanza `json:"stanzas"`
+}
+
+// GetVerse godoc
+// @Summary Get a single verse
+// @Description Return one stanza of the song's lyrics. The ETag header carries the stanza's content hash, which edits to it must send in If-Match.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Success 200 {object} lyrics.Stanza
+// @Failure 400 {object} utils.Problem "Invalid song ID or verse"
+// @Failure 404 {object} utils.Problem "Song or verse not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch verse"
+// @Router /songs/{id}/lyrics/verses/{n} [get]
+func (h *SongHandler) GetVerse(w http.ResponseWriter, r *http.Request) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return
+	}
+
+	stanza, err := h.songService.GetVerse(r.Context(), songID, verse)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch verse")
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(stanza.Hash))
+	utils.RespondWithJSON(w, http.StatusOK, stanza)
+}
+
+// GetLine godoc
+// @Summary Get a single line of a verse
+// @Description Return one line of a stanza. The ETag header carries the stanza's content hash, which edits to the line must send in If-Match.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param line path int true "Line index within the verse (0-based)"
+// @Success 200 {object} LineResponse
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse or line"
+// @Failure 404 {object} utils.Problem "Song, verse or line not found"
+// @Failure 500 {object} utils.Problem "Failed to fetch line"
+// @Router /songs/{id}/lyrics/verses/{n}/lines/{line} [get]
+func (h *SongHandler) GetLine(w http.ResponseWriter, r *http.Request) {
+	songID, verse, line, ok := h.linePath(w, r)
+	if !ok {
+		return
+	}
+
+	stanza, err := h.songService.GetVerse(r.Context(), songID, verse)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to fetch line")
+		return
+	}
+	if line >= len(stanza.Lines) {
+		respondError(w, r, h.loggers, domain.NotFound(lyrics.ErrLineNotFound), "Failed to fetch line")
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(stanza.Hash))
+	utils.RespondWithJSON(w, http.StatusOK, LineResponse{Verse: verse, Line: line, Text: stanza.Lines[line], Hash: stanza.Hash})
+}
+
+// InsertVerse godoc
+// @Summary Insert a verse
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param verse body VerseRequest true "New verse"
+// @Success 201 {object} VersesResponse
+// @Header 201 {string} Location "/songs/{id}/lyrics/verses/{n}"
+// @Header 201 {string} ETag "Hash of the new verse"
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse or payload"
+// @Failure 422 {object} utils.Problem "Invalid verse"
+// @Failure 404 {object} utils.Problem "Song not found"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses [post]
+func (h *SongHandler) InsertVerse(w http.ResponseWriter, r *http.Request) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerseRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	edit := lyrics.Edit{Op: lyrics.OpInsertVerse, Verse: -1, Label: req.Label, Lines: req.Lines}
+	if req.Position != nil {
+		edit.Verse = *req.Position
+	}
+
+	h.editLyrics(w, r, songID, edit)
+}
+
+// ReplaceVerse godoc
+// @Summary Replace a verse
+// @Description Replace the lines (and optionally the label) of one stanza. Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Param verse body VerseRequest true "Replacement verse"
+// @Success 200 {object} VersesResponse
+// @Header 200 {string} ETag "New hash of the edited verse"
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse or payload"
+// @Failure 422 {object} utils.Problem "Invalid verse"
+// @Failure 404 {object} utils.Problem "Song or verse not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n} [patch]
+func (h *SongHandler) ReplaceVerse(w http.ResponseWriter, r *http.Request) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerseRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	h.editLyrics(w, r, songID, lyrics.Edit{Op: lyrics.OpReplaceVerse, Verse: verse, Label: req.Label, Lines: req.Lines})
+}
+
+// DeleteVerse godoc
+// @Summary Delete a verse
+// @Description Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Success 200 {object} VersesResponse
+// @Failure 404 {object} utils.Problem "Song or verse not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n} [delete]
+func (h *SongHandler) DeleteVerse(w http.ResponseWriter, r *http.Request) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return
+	}
+
+	h.editLyrics(w, r, songID, lyrics.Edit{Op: lyrics.OpDeleteVerse, Verse: verse})
+}
+
+// MoveVerse godoc
+// @Summary Move a verse
+// @Description Move a stanza to another position. Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Param move body MoveVerseRequest true "Target position"
+// @Success 200 {object} VersesResponse
+// @Header 200 {string} ETag "Hash of the moved verse"
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse or payload"
+// @Failure 422 {object} utils.Problem "Invalid target position"
+// @Failure 404 {object} utils.Problem "Song or verse not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n}/move [post]
+func (h *SongHandler) MoveVerse(w http.ResponseWriter, r *http.Request) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req MoveVerseRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	h.editLyrics(w, r, songID, lyrics.Edit{Op: lyrics.OpMoveVerse, Verse: verse, To: req.To})
+}
+
+// InsertLine godoc
+// @Summary Insert a line into a verse
+// @Description Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Param line body LineRequest true "New line"
+// @Success 201 {object} VersesResponse
+// @Header 201 {string} Location "/songs/{id}/lyrics/verses/{n}/lines/{line}"
+// @Header 201 {string} ETag "New hash of the verse"
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse, line or payload"
+// @Failure 422 {object} utils.Problem "Invalid line"
+// @Failure 404 {object} utils.Problem "Song, verse or line not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n}/lines [post]
+func (h *SongHandler) InsertLine(w http.ResponseWriter, r *http.Request) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req LineRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	edit := lyrics.Edit{Op: lyrics.OpInsertLine, Verse: verse, Line: -1, Text: req.Text}
+	if req.Position != nil {
+		edit.Line = *req.Position
+	}
+
+	h.editLyrics(w, r, songID, edit)
+}
+
+// ReplaceLine godoc
+// @Summary Replace a line of a verse
+// @Description Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param line path int true "Line index within the verse (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Param text body LineRequest true "Replacement line"
+// @Success 200 {object} VersesResponse
+// @Header 200 {string} ETag "New hash of the edited verse"
+// @Failure 400 {object} utils.Problem "Invalid song ID, verse, line or payload"
+// @Failure 422 {object} utils.Problem "Invalid line"
+// @Failure 404 {object} utils.Problem "Song, verse or line not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n}/lines/{line} [patch]
+func (h *SongHandler) ReplaceLine(w http.ResponseWriter, r *http.Request) {
+	songID, verse, line, ok := h.linePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req LineRequest
+	if !decodeJSON(w, r, h.loggers, &req) {
+		return
+	}
+
+	h.editLyrics(w, r, songID, lyrics.Edit{Op: lyrics.OpReplaceLine, Verse: verse, Line: line, Text: req.Text})
+}
+
+// DeleteLine godoc
+// @Summary Delete a line of a verse
+// @Description Deleting the last line of a verse deletes the verse. Requires If-Match with the stanza's current hash.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse index (0-based)"
+// @Param line path int true "Line index within the verse (0-based)"
+// @Param If-Match header string true "Current verse hash"
+// @Success 200 {object} VersesResponse
+// @Header 200 {string} ETag "New hash of the edited verse, unless deleting its last line removed it"
+// @Failure 404 {object} utils.Problem "Song, verse or line not found"
+// @Failure 412 {object} utils.Problem "Verse was modified"
+// @Failure 428 {object} utils.Problem "If-Match required"
+// @Failure 500 {object} utils.Problem "Failed to edit lyrics"
+// @Router /songs/{id}/lyrics/verses/{n}/lines/{line} [delete]
+func (h *SongHandler) DeleteLine(w http.ResponseWriter, r *http.Request) {
+	songID, verse, line, ok := h.linePath(w, r)
+	if !ok {
+		return
+	}
+
+	h.editLyrics(w, r, songID, lyrics.Edit{Op: lyrics.OpDeleteLine, Verse: verse, Line: line})
+}
+
+// editLyrics applies edit and responds with the resulting stanzas. The
+// ETag header carries the edited stanza's new hash, for the next edit's
+// If-Match; inserts are answered with 201 and the Location of the new
+// stanza or line.
+func (h *SongHandler) editLyrics(w http.ResponseWriter, r *http.Request, songID int, edit lyrics.Edit) {
+	stanzas, target, err := h.songService.EditLyrics(r.Context(), songID, ifMatchHash(r), edit)
+	if err != nil {
+		respondError(w, r, h.loggers, err, "Failed to edit lyrics")
+		return
+	}
+
+	status := http.StatusOK
+	if edit.Op == lyrics.OpInsertVerse || edit.Op == lyrics.OpInsertLine {
+		status = http.StatusCreated
+	}
+	if target >= 0 && target < len(stanzas) {
+		w.Header().Set("ETag", strconv.Quote(stanzas[target].Hash))
+		switch edit.Op {
+		case lyrics.OpInsertVerse:
+			w.Header().Set("Location", fmt.Sprintf("/songs/%d/lyrics/verses/%d", songID, target))
+		case lyrics.OpInsertLine:
+			line := edit.Line
+			if line < 0 {
+				line = len(stanzas[target].Lines) - 1
+			}
+			w.Header().Set("Location", fmt.Sprintf("/songs/%d/lyrics/verses/%d/lines/%d", songID, target, line))
+		}
+	}
+
+	h.loggers.InfoLogger.Info("Edited lyrics successfully", slog.Int("songID", songID), slog.String("op", string(edit.Op)))
+	utils.RespondWithJSON(w, status, VersesResponse{Stanzas: stanzas})
+}
+
+// ifMatchHash returns the entity tag of the If-Match header without its
+// quotes or weak prefix.
+func ifMatchHash(r *http.Request) string {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	tag = strings.TrimPrefix(tag, "W/")
+	return strings.Trim(tag, `"`)
+}
+
+func (h *SongHandler) versePath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	songID, ok := h.songID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	verse, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || verse < 0 {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid verse index")
+		return 0, 0, false
+	}
+	return songID, verse, true
+}
+
+func (h *SongHandler) linePath(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+	songID, verse, ok := h.versePath(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	line, err := strconv.Atoi(chi.URLParam(r, "line"))
+	if err != nil || line < 0 {
+		utils.RespondWithErrorJSON(w, r, http.StatusBadRequest, "Invalid line index")
+		return 0, 0, 0, false
+	}
+	return songID, verse, line, true
+}
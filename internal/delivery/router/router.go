@@ -2,6 +2,7 @@ package router
 
 import (
 	"music-service/internal/delivery/handler"
+	"net/http"
 
 	_ "music-service/docs"
 
@@ -10,7 +11,10 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter(songHandler *handler.SongHandler) *chi.Mux {
+// NewRouter builds the API's routes. idempotency wraps the POST routes that
+// answer with a single resource; streaming ones such as the import are left
+// out, as their request and response bodies are too large to store.
+func NewRouter(songHandler *handler.SongHandler, savedSearchHandler *handler.SavedSearchHandler, annotationHandler *handler.AnnotationHandler, idempotency func(http.Handler) http.Handler) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
@@ -20,10 +24,57 @@ func NewRouter(songHandler *handler.SongHandler) *chi.Mux {
 
 	r.Route("/songs", func(r chi.Router) {
 		r.Get("/", songHandler.GetSongs)
+		r.Get("/export", songHandler.ExportSongs)
 		r.Get("/{id}/lyrics", songHandler.GetSongLyricsPaginated)
+		r.Get("/{id}/lyrics/search", songHandler.SearchSongLyrics)
+		r.Get("/{id}/lyrics/lrc", songHandler.DownloadSyncedLyrics)
+		r.Put("/{id}/lyrics/lrc", songHandler.UploadSyncedLyrics)
+		r.Delete("/{id}/lyrics/lrc", songHandler.DeleteSyncedLyrics)
+		r.Get("/{id}/lyrics/at", songHandler.GetSyncedLineAt)
+		r.Get("/{id}/lyrics/export", songHandler.ExportSongLyrics)
+		r.Get("/{id}/lyrics/stats", songHandler.GetLyricsStats)
+		r.Get("/{id}/lyrics/structure", songHandler.GetLyricsStructure)
+		r.With(idempotency).Post("/{id}/lyrics/verses", songHandler.InsertVerse)
+		r.Get("/{id}/lyrics/verses/{n}", songHandler.GetVerse)
+		r.Patch("/{id}/lyrics/verses/{n}", songHandler.ReplaceVerse)
+		r.Delete("/{id}/lyrics/verses/{n}", songHandler.DeleteVerse)
+		r.With(idempotency).Post("/{id}/lyrics/verses/{n}/move", songHandler.MoveVerse)
+		r.With(idempotency).Post("/{id}/lyrics/verses/{n}/lines", songHandler.InsertLine)
+		r.Get("/{id}/lyrics/verses/{n}/lines/{line}", songHandler.GetLine)
+		r.Patch("/{id}/lyrics/verses/{n}/lines/{line}", songHandler.ReplaceLine)
+		r.Delete("/{id}/lyrics/verses/{n}/lines/{line}", songHandler.DeleteLine)
+		r.Put("/{id}/language", songHandler.SetSongLanguage)
+		r.Delete("/{id}/language", songHandler.ClearSongLanguage)
+		r.Get("/{id}/explicit", songHandler.GetSongExplicit)
+		r.Put("/{id}/explicit", songHandler.SetSongExplicit)
+		r.Delete("/{id}/explicit", songHandler.ClearSongExplicit)
+		r.Get("/{id}/annotations", annotationHandler.GetAnnotations)
+		r.With(idempotency).Post("/{id}/annotations", annotationHandler.CreateAnnotation)
+		r.Get("/{id}/annotations/{annotationID}", annotationHandler.GetAnnotation)
+		r.Delete("/{id}/annotations/{annotationID}", annotationHandler.DeleteAnnotation)
+		r.With(idempotency).Post("/{id}/annotations/{annotationID}/votes", annotationHandler.VoteAnnotation)
+		r.Get("/{id}", songHandler.GetSong)
 		r.Delete("/{id}", songHandler.DeleteSong)
 		r.Put("/{id}", songHandler.UpdateSong)
-		r.Post("/", songHandler.AddSong)
+		r.Patch("/{id}", songHandler.PatchSong)
+		r.With(idempotency).Post("/", songHandler.AddSong)
+		r.Post("/import", songHandler.ImportSongs)
+		r.With(idempotency).Post("/bulk", songHandler.BulkUpdateSongs)
+	})
+
+	r.Route("/artists", func(r chi.Router) {
+		r.Get("/{name}/lyrics/export", songHandler.ExportArtistLyrics)
+		r.Get("/{name}/vocabulary", songHandler.GetArtistVocabulary)
+	})
+
+	r.Route("/saved-searches", func(r chi.Router) {
+		r.Get("/", savedSearchHandler.GetSavedSearches)
+		r.With(idempotency).Post("/", savedSearchHandler.CreateSavedSearch)
+		r.Get("/{id}", savedSearchHandler.GetSavedSearch)
+		r.Delete("/{id}", savedSearchHandler.DeleteSavedSearch)
+		r.Get("/{id}/results", savedSearchHandler.GetSavedSearchResults)
+		r.Get("/{id}/new", savedSearchHandler.GetNewSavedSearchResults)
+		r.With(idempotency).Post("/{id}/check", savedSearchHandler.MarkSavedSearchChecked)
 	})
 
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
@@ -1,16 +1,37 @@
 package domain
 
 import (
+	"music-service/pkg/explicit"
+	"music-service/pkg/lyrics"
 	"time"
 )
 
+// Sources record whether a song's language or explicit flag was detected
+// from its lyrics or set by hand. Manual values are never re-detected.
+const (
+	SourceDetected = "detected"
+	SourceManual   = "manual"
+)
+
+// Song.RawText keeps the lyrics as submitted; Text holds them normalized.
+// Version is bumped by every write and reported as the song's ETag.
 type Song struct {
-	ID          int       `json:"id"`
-	Group       string    `json:"group"`
-	Song        string    `json:"song"`
-	ReleaseDate time.Time `json:"release_date"`
-	Text        string    `json:"text"`
-	Link        string    `json:"link"`
+	ID                 int            `json:"id"`
+	Group              string         `json:"group"`
+	Song               string         `json:"song"`
+	ReleaseDate        time.Time      `json:"release_date"`
+	Text               string         `json:"text"`
+	RawText            string         `json:"-"`
+	Link               string         `json:"link"`
+	Tags               []string       `json:"tags"`
+	Language           string         `json:"language,omitempty"`
+	LanguageConfidence float64        `json:"language_confidence,omitempty"`
+	LanguageSource     string         `json:"language_source,omitempty"`
+	Explicit           bool           `json:"explicit"`
+	ExplicitSource     string         `json:"explicit_source,omitempty"`
+	ExplicitHits       []explicit.Hit `json:"-"`
+	CreatedAt          time.Time      `json:"created_at"`
+	Version            int            `json:"-"`
 }
 
 type SongRequest struct {
@@ -27,3 +48,19 @@ type SongDetail struct {
 type SongDetailFetcher interface {
 	FetchSongDetails(group, song string) (*SongDetail, error)
 }
+
+// Annotation is commentary attached to a range of a song's lyrics. Quote is
+// the anchored text at the time it was last resolved; when an edit removes
+// it from the lyrics the annotation is kept but marked Orphaned.
+type Annotation struct {
+	ID        int           `json:"id"`
+	SongID    int           `json:"song_id"`
+	Author    string        `json:"author"`
+	Body      string        `json:"body"`
+	Anchor    lyrics.Anchor `json:"anchor"`
+	Quote     string        `json:"quote"`
+	Votes     int           `json:"votes"`
+	Orphaned  bool          `json:"orphaned"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds classify failures independently of the layer they come from.
+// Errors returned by the repository and service layers carry one of them,
+// so callers check the kind with errors.Is instead of knowing every
+// sentinel.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// Error attaches a kind to an underlying error. It reports the underlying
+// error's message, and errors.Is matches both the kind and the error.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Err, e.Kind}
+}
+
+// NotFound marks err as referring to something that does not exist.
+func NotFound(err error) error {
+	return &Error{Kind: ErrNotFound, Err: err}
+}
+
+// Conflict marks err as clashing with the current state of the data.
+func Conflict(err error) error {
+	return &Error{Kind: ErrConflict, Err: err}
+}
+
+// Validation marks err as caused by invalid input.
+func Validation(err error) error {
+	return &Error{Kind: ErrValidation, Err: err}
+}
+
+// Unavailable marks err as a temporary failure of a dependency, such as a
+// lost database connection.
+func Unavailable(err error) error {
+	return &Error{Kind: ErrUnavailable, Err: err}
+}
+
+// FieldError describes why one field of an input is invalid. Code is a
+// stable machine-readable reason such as "required" or "too_long".
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationError lists every invalid field of an input. It is of kind
+// ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "invalid input: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
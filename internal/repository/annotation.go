@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+
+	"log/slog"
+)
+
+var ErrAnnotationNotFound = domain.NotFound(errors.New("annotation not found"))
+
+type AnnotationRepository interface {
+	GetAnnotations(ctx context.Context, songID int) ([]domain.Annotation, error)
+	GetAnnotationByID(ctx context.Context, songID, id int) (*domain.Annotation, error)
+	CreateAnnotation(ctx context.Context, annotation domain.Annotation) (*domain.Annotation, error)
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+	VoteAnnotation(ctx context.Context, songID, id int, voter string, vote int) (*domain.Annotation, error)
+}
+
+const annotationColumns = "id, song_id, author, body, verse, line, start_offset, end_offset, quote, votes, orphaned, created_at, updated_at"
+
+type annotationRepository struct {
+	db     *sql.DB
+	logger *logger.Loggers
+}
+
+func NewAnnotationRepository(db *sql.DB, logger *logger.Loggers) AnnotationRepository {
+	return &annotationRepository{db: db, logger: logger}
+}
+
+func scanAnnotation(row rowScanner) (domain.Annotation, error) {
+	var a domain.Annotation
+	var line sql.NullInt64
+	err := row.Scan(&a.ID, &a.SongID, &a.Author, &a.Body, &a.Anchor.Verse, &line, &a.Anchor.Start, &a.Anchor.End, &a.Quote, &a.Votes, &a.Orphaned, &a.CreatedAt, &a.UpdatedAt)
+	if line.Valid {
+		n := int(line.Int64)
+		a.Anchor.Line = &n
+	}
+	return a, err
+}
+
+// lineArg stores a stanza-wide anchor's missing line as NULL.
+func lineArg(line *int) interface{} {
+	if line == nil {
+		return nil
+	}
+	return *line
+}
+
+func (r *annotationRepository) GetAnnotations(ctx context.Context, songID int) ([]domain.Annotation, error) {
+	r.logger.DebugLogger.Debug("Entering GetAnnotations", slog.Int("songID", songID))
+
+	if err := songExists(ctx, r.db, songID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + annotationColumns + " FROM annotations WHERE song_id = $1 ORDER BY verse, line NULLS FIRST, start_offset, id"
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Int("songID", songID))
+
+	rows, err := r.db.QueryContext(ctx, query, songID)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching annotations", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	annotations := []domain.Annotation{}
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			r.logger.ErrorLogger.Error("Error scanning annotation row", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+		annotations = append(annotations, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorLogger.Error("Error iterating over annotation rows", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	return annotations, nil
+}
+
+func (r *annotationRepository) GetAnnotationByID(ctx context.Context, songID, id int) (*domain.Annotation, error) {
+	query := "SELECT " + annotationColumns + " FROM annotations WHERE song_id = $1 AND id = $2"
+
+	a, err := scanAnnotation(r.db.QueryRowContext(ctx, query, songID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAnnotationNotFound
+	}
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching annotation", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	return &a, nil
+}
+
+func (r *annotationRepository) CreateAnnotation(ctx context.Context, annotation domain.Annotation) (*domain.Annotation, error) {
+	r.logger.DebugLogger.Debug("Entering CreateAnnotation", slog.Any("annotation", annotation))
+
+	query := `
+		INSERT INTO annotations (song_id, author, body, verse, line, start_offset, end_offset, quote)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + annotationColumns
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query))
+
+	a := annotation.Anchor
+	created, err := scanAnnotation(r.db.QueryRowContext(ctx, query, annotation.SongID, annotation.Author, annotation.Body, a.Verse, lineArg(a.Line), a.Start, a.End, annotation.Quote))
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error creating annotation", slog.Int("songID", annotation.SongID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully created annotation", slog.Int("id", created.ID))
+	return &created, nil
+}
+
+func (r *annotationRepository) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	r.logger.DebugLogger.Debug("Entering DeleteAnnotation", slog.Int("songID", songID), slog.Int("id", id))
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM annotations WHERE song_id = $1 AND id = $2", songID, id)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error deleting annotation", slog.Int("id", id), slog.Any("error", err))
+		return dbError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if affected == 0 {
+		return ErrAnnotationNotFound
+	}
+
+	r.logger.InfoLogger.Info("Successfully deleted annotation", slog.Int("id", id))
+	return nil
+}
+
+// VoteAnnotation records voter's vote on an annotation and adjusts its
+// score by the difference to the voter's previous vote, if any. The
+// annotation row is locked first, so concurrent votes of one voter are
+// counted once.
+func (r *annotationRepository) VoteAnnotation(ctx context.Context, songID, id int, voter string, vote int) (*domain.Annotation, error) {
+	r.logger.DebugLogger.Debug("Entering VoteAnnotation", slog.Int("id", id), slog.Int("vote", vote))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM annotations WHERE song_id = $1 AND id = $2 FOR UPDATE", songID, id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAnnotationNotFound
+	}
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error locking annotation", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	var previous int
+	err = tx.QueryRowContext(ctx, "SELECT vote FROM annotation_votes WHERE annotation_id = $1 AND voter = $2", id, voter).Scan(&previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.ErrorLogger.Error("Error fetching previous vote", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	upsert := `INSERT INTO annotation_votes (annotation_id, voter, vote) VALUES ($1, $2, $3)
+		ON CONFLICT (annotation_id, voter) DO UPDATE SET vote = EXCLUDED.vote`
+	if _, err := tx.ExecContext(ctx, upsert, id, voter, vote); err != nil {
+		r.logger.ErrorLogger.Error("Error recording vote", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	query := "UPDATE annotations SET votes = votes + $2 WHERE id = $1 RETURNING " + annotationColumns
+	a, err := scanAnnotation(tx.QueryRowContext(ctx, query, id, vote-previous))
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error voting on annotation", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.ErrorLogger.Error("Error committing vote", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	return &a, nil
+}
+
+// reanchorAnnotations moves each of a song's annotations to wherever its
+// quote now appears in the edited lyrics. Annotations whose quote is gone
+// keep their old anchor and are flagged orphaned; an orphan whose quote
+// comes back is re-attached.
+func reanchorAnnotations(ctx context.Context, tx *sql.Tx, songID int, stanzas []lyrics.Stanza) error {
+	rows, err := tx.QueryContext(ctx, "SELECT "+annotationColumns+" FROM annotations WHERE song_id = $1 FOR UPDATE", songID)
+	if err != nil {
+		return err
+	}
+
+	var annotations []domain.Annotation
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		annotations = append(annotations, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE annotations
+		SET verse = $2, line = $3, start_offset = $4, end_offset = $5, orphaned = $6, updated_at = now()
+		WHERE id = $1
+	`
+	for _, a := range annotations {
+		anchor, found := lyrics.Reanchor(stanzas, a.Anchor, a.Quote)
+		if found == !a.Orphaned && sameAnchor(anchor, a.Anchor) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, query, a.ID, anchor.Verse, lineArg(anchor.Line), anchor.Start, anchor.End, !found); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sameAnchor(a, b lyrics.Anchor) bool {
+	if (a.Line == nil) != (b.Line == nil) || (a.Line != nil && *a.Line != *b.Line) {
+		return false
+	}
+	return a.Verse == b.Verse && a.Start == b.Start && a.End == b.End
+}
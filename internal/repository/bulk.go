@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"slices"
+
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+// ErrBulkLimitExceeded is returned when a bulk change would touch more songs
+// than allowed.
+var ErrBulkLimitExceeded = domain.Validation(errors.New("filter matches too many songs"))
+
+// BulkOptions control a bulk change. MaxAffected caps the number of songs
+// the filter may match; zero means no cap. A dry run makes the change and
+// rolls it back, so its result is exact, and is not audited. Audit is
+// recorded in the audit log under Action.
+type BulkOptions struct {
+	DryRun      bool
+	MaxAffected int
+	SampleSize  int
+	Action      string
+	Audit       interface{}
+}
+
+// BulkResult reports a bulk change. Matched counts the songs the filter
+// selected and Affected those actually changed, whose IDs are in SongIDs.
+// Sample holds the first changed songs as they are after the change, or,
+// for deletes, as they were. A dry run whose filter matches more songs than
+// allowed reports only Matched, a sample of matches and LimitExceeded.
+type BulkResult struct {
+	Matched       int
+	Affected      int
+	SongIDs       []int
+	Sample        []domain.Song
+	LimitExceeded bool
+}
+
+// DeleteSongsWhere deletes every song matching filter in one transaction.
+func (r *songRepository) DeleteSongsWhere(ctx context.Context, filter SongFilter, opts BulkOptions) (*BulkResult, error) {
+	r.logger.DebugLogger.Debug("Entering DeleteSongsWhere", slog.Any("filter", filter), slog.Bool("dryRun", opts.DryRun))
+
+	return r.bulk(ctx, filter, opts, func(tx *sql.Tx, songs []domain.Song, result *BulkResult) error {
+		ids := make([]int, len(songs))
+		for i, song := range songs {
+			ids[i] = song.ID
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM songs WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+			return err
+		}
+		result.SongIDs = ids
+		result.Sample = songs[:min(len(songs), opts.SampleSize)]
+		return nil
+	})
+}
+
+// ModifySongsWhere lets fn change every song matching filter, in ID order,
+// and writes back the columns it changed in one transaction. An error from
+// fn aborts the whole change and is returned as-is.
+func (r *songRepository) ModifySongsWhere(ctx context.Context, filter SongFilter, opts BulkOptions, fn func(song *domain.Song) error) (*BulkResult, error) {
+	r.logger.DebugLogger.Debug("Entering ModifySongsWhere", slog.Any("filter", filter), slog.Bool("dryRun", opts.DryRun))
+
+	return r.bulk(ctx, filter, opts, func(tx *sql.Tx, songs []domain.Song, result *BulkResult) error {
+		for _, song := range songs {
+			previous := song
+			previous.Tags = slices.Clone(song.Tags)
+			if err := fn(&song); err != nil {
+				return fmt.Errorf("song %d: %w", previous.ID, err)
+			}
+			song.ID, song.Version = previous.ID, previous.Version
+			if err := updateSongColumns(ctx, tx, previous, &song); err != nil {
+				return err
+			}
+			if song.Version == previous.Version {
+				continue
+			}
+			result.SongIDs = append(result.SongIDs, song.ID)
+			if len(result.Sample) < opts.SampleSize {
+				result.Sample = append(result.Sample, song)
+			}
+		}
+		return nil
+	})
+}
+
+// bulk locks the songs matching filter, checks them against the limit and
+// lets change apply the bulk change, then audits and commits it, or rolls
+// it back for a dry run.
+func (r *songRepository) bulk(ctx context.Context, filter SongFilter, opts BulkOptions, change func(tx *sql.Tx, songs []domain.Song, result *BulkResult) error) (*BulkResult, error) {
+	where, args, err := filter.where(1)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Invalid song filter", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer tx.Rollback()
+
+	result := &BulkResult{}
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM songs WHERE "+where, args...).Scan(&result.Matched); err != nil {
+		r.logger.ErrorLogger.Error("Error counting songs", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	if opts.MaxAffected > 0 && result.Matched > opts.MaxAffected {
+		if !opts.DryRun {
+			return nil, fmt.Errorf("%w: %d songs match, the limit is %d", ErrBulkLimitExceeded, result.Matched, opts.MaxAffected)
+		}
+		result.LimitExceeded = true
+		result.Sample, err = r.GetSongs(ctx, filter, Page{Limit: opts.SampleSize})
+		return result, err
+	}
+
+	songs, err := lockSongs(ctx, tx, where, args)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error locking songs", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	// Songs may have been added or changed between the count and the
+	// lock; the locked set is the one changed.
+	result.Matched = len(songs)
+	if opts.MaxAffected > 0 && result.Matched > opts.MaxAffected {
+		return nil, fmt.Errorf("%w: %d songs match, the limit is %d", ErrBulkLimitExceeded, result.Matched, opts.MaxAffected)
+	}
+
+	if err := change(tx, songs, result); err != nil {
+		return nil, dbError(err)
+	}
+	result.Affected = len(result.SongIDs)
+
+	if opts.DryRun {
+		r.logger.InfoLogger.Info("Dry-ran bulk change", slog.String("action", opts.Action), slog.Int("affected", result.Affected))
+		return result, nil
+	}
+
+	details, err := json.Marshal(opts.Audit)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO audit_log (action, details, affected, song_ids) VALUES ($1, $2, $3, $4)",
+		opts.Action, string(details), result.Affected, pq.Array(result.SongIDs)); err != nil {
+		r.logger.ErrorLogger.Error("Error writing audit log", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully applied bulk change", slog.String("action", opts.Action), slog.Int("affected", result.Affected))
+	return result, nil
+}
+
+func lockSongs(ctx context.Context, tx *sql.Tx, where string, args []interface{}) ([]domain.Song, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+songColumns+" FROM songs WHERE "+where+" ORDER BY id FOR UPDATE", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []domain.Song
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, rows.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"music-service/internal/domain"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// DBError is a database failure given a domain kind by dbError. Its message
+// is the driver's, which can name tables, constraints and values, so it is
+// for logs rather than clients.
+type DBError struct {
+	Err error
+}
+
+func (e *DBError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+// dbError gives a database failure its domain kind, wrapped in a DBError.
+// Lost connections, timeouts and an overloaded or restarting server are
+// Unavailable, constraint violations and serialization failures Conflict,
+// and values Postgres rejects Validation. Errors that already carry a
+// kind, and ones that fit none of these, are returned unchanged.
+func dbError(err error) error {
+	var kinded *domain.Error
+	if err == nil || errors.As(err, &kinded) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return domain.Unavailable(&DBError{Err: err})
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return domain.Unavailable(&DBError{Err: err})
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "53", "57": // connection exception, insufficient resources, operator intervention
+			return domain.Unavailable(&DBError{Err: err})
+		case "23", "40": // integrity constraint violation, transaction rollback
+			return domain.Conflict(&DBError{Err: err})
+		case "22": // data exception
+			return domain.Validation(&DBError{Err: err})
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"music-service/internal/domain"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"timeout", context.DeadlineExceeded, domain.ErrUnavailable},
+		{"bad connection", driver.ErrBadConn, domain.ErrUnavailable},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, domain.ErrUnavailable},
+		{"unique violation", &pq.Error{Code: "23505"}, domain.ErrConflict},
+		{"serialization failure", &pq.Error{Code: "40001"}, domain.ErrConflict},
+		{"value out of range", &pq.Error{Code: "22003"}, domain.ErrValidation},
+	}
+	for _, tt := range tests {
+		err := dbError(tt.err)
+		var dbErr *DBError
+		if !errors.Is(err, tt.kind) || !errors.Is(err, tt.err) || !errors.As(err, &dbErr) {
+			t.Errorf("%s: dbError = %v, want a DBError of kind %v", tt.name, err, tt.kind)
+		}
+	}
+
+	syntax := &pq.Error{Code: "42601"}
+	if err := dbError(syntax); err != syntax {
+		t.Errorf("dbError(syntax error) = %v, want it unchanged", err)
+	}
+	if err := dbError(ErrSongNotFound); err != ErrSongNotFound {
+		t.Errorf("dbError(ErrSongNotFound) = %v, want it unchanged", err)
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"music-service/internal/domain"
+	"strconv"
+
+	"log/slog"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at a
+// time, which bounds the memory an export holds.
+const exportFetchSize = 500
+
+// ExportSongs calls fn with every song matching filter, in ID order. The
+// songs are read through a server-side cursor in a read-only REPEATABLE
+// READ transaction, so the export is one consistent snapshot however long
+// it runs. An error from fn stops the export and is returned as-is.
+func (r *songRepository) ExportSongs(ctx context.Context, filter SongFilter, fn func(song domain.Song) error) error {
+	r.logger.DebugLogger.Debug("Entering ExportSongs", slog.Any("filter", filter))
+
+	where, args, err := filter.where(1)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Invalid song filter", slog.Any("error", err))
+		return dbError(err)
+	}
+	query := "DECLARE export_songs NO SCROLL CURSOR FOR SELECT " + songColumns + " FROM songs WHERE " + where + " ORDER BY id"
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("args", args))
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return dbError(err)
+	}
+	// The transaction only reads, so rolling it back also closes the cursor.
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		r.logger.ErrorLogger.Error("Error declaring export cursor", slog.Any("error", err))
+		return dbError(err)
+	}
+
+	fetch := "FETCH FORWARD " + strconv.Itoa(exportFetchSize) + " FROM export_songs"
+	total := 0
+	for {
+		n, err := fetchSongs(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		total += n
+		if n < exportFetchSize {
+			break
+		}
+	}
+
+	r.logger.InfoLogger.Info("Successfully exported songs", slog.Int("count", total))
+	return nil
+}
+
+// fetchSongs runs one FETCH and hands each row to fn, returning the number
+// of rows fetched.
+func fetchSongs(ctx context.Context, tx *sql.Tx, fetch string, fn func(song domain.Song) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			return n, dbError(err)
+		}
+		n++
+		if err := fn(song); err != nil {
+			return n, err
+		}
+	}
+	return n, dbError(rows.Err())
+}
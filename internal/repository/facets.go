@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"music-service/internal/domain"
+	"strconv"
+	"strings"
+
+	"log/slog"
+)
+
+// Facet names accepted by ListSongs.
+const (
+	FacetArtist    = "artist"
+	FacetDecade    = "decade"
+	FacetTag       = "tag"
+	FacetLanguage  = "language"
+	FacetHasLyrics = "has_lyrics"
+)
+
+type FacetRequest struct {
+	Name  string
+	Limit int
+}
+
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// facetQueries maps each facet to the expression it groups by and any extra
+// FROM items or conditions it needs.
+var facetQueries = map[string]struct {
+	value     string
+	from      string
+	condition string
+}{
+	FacetArtist:    {value: "group_name"},
+	FacetDecade:    {value: "((EXTRACT(YEAR FROM release_date)::int / 10) * 10)::text", condition: "release_date IS NOT NULL"},
+	FacetTag:       {value: "tag", from: ", unnest(tags) AS tag"},
+	FacetLanguage:  {value: "language", condition: "language IS NOT NULL"},
+	FacetHasLyrics: {value: "(COALESCE(text, '') <> '')::text"},
+}
+
+// facetQueryFields lists the q fields that constrain each facet.
+var facetQueryFields = map[string][]string{
+	FacetArtist:   {"artist", "group"},
+	FacetDecade:   {"year", "date"},
+	FacetTag:      {"tag", "genre"},
+	FacetLanguage: {"lang", "language"},
+}
+
+func IsFacet(name string) bool {
+	_, ok := facetQueries[name]
+	return ok
+}
+
+// without drops the part of the filter that corresponds to a facet, so that
+// the facet's own buckets are counted as if it were not selected. That
+// includes the terms of the q expression on the facet's fields, as long as
+// they are ANDed with the rest of it.
+func (f SongFilter) without(facet string) SongFilter {
+	switch facet {
+	case FacetArtist:
+		f.Artist = ""
+		f.Group = ""
+	case FacetDecade:
+		f.Decade = 0
+	case FacetTag:
+		f.Tag = ""
+	case FacetLanguage:
+		f.Language = ""
+	case FacetHasLyrics:
+		f.HasLyrics = nil
+	}
+	f.withoutFacet = facet
+	return f
+}
+
+// getFacets counts songs per bucket for each requested facet. All facets are
+// computed by a single UNION ALL query so the database is hit only once.
+func (r *songRepository) getFacets(ctx context.Context, q querier, filter SongFilter, facets []FacetRequest) (map[string][]FacetBucket, error) {
+	r.logger.DebugLogger.Debug("Entering GetFacets", slog.Any("filter", filter), slog.Any("facets", facets))
+
+	result := make(map[string][]FacetBucket, len(facets))
+	if len(facets) == 0 {
+		return result, nil
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, facet := range facets {
+		fq, ok := facetQueries[facet.Name]
+		if !ok {
+			return nil, domain.Validation(fmt.Errorf("unknown facet %q", facet.Name))
+		}
+		result[facet.Name] = []FacetBucket{}
+
+		where, whereArgs, err := filter.without(facet.Name).where(len(args) + 2)
+		if err != nil {
+			r.logger.ErrorLogger.Error("Invalid song filter", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+		if fq.condition != "" {
+			where += " AND " + fq.condition
+		}
+
+		parts = append(parts, "(SELECT $"+strconv.Itoa(len(args)+1)+"::text AS facet, "+fq.value+" AS value, COUNT(*) AS count"+
+			" FROM songs"+fq.from+" WHERE "+where+
+			" GROUP BY 2 ORDER BY 3 DESC, 2 LIMIT $"+strconv.Itoa(len(args)+len(whereArgs)+2)+")")
+		args = append(args, facet.Name)
+		args = append(args, whereArgs...)
+		args = append(args, facet.Limit)
+	}
+
+	query := strings.Join(parts, " UNION ALL ")
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("args", args))
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error executing GetFacets query", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var bucket FacetBucket
+		if err := rows.Scan(&name, &bucket.Value, &bucket.Count); err != nil {
+			r.logger.ErrorLogger.Error("Error scanning facet row", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+		result[name] = append(result[name], bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorLogger.Error("Error iterating over facet rows", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"music-service/internal/domain"
+	"music-service/pkg/query"
+	"strconv"
+	"time"
+)
+
+// songQuerySchema maps the fields of the q search language onto the songs
+// table.
+var songQuerySchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":       {Column: "id", Type: query.Number},
+		"artist":   {Column: "group_name", Type: query.Text},
+		"group":    {Column: "group_name", Type: query.Text},
+		"song":     {Column: "song_name", Type: query.Text},
+		"title":    {Column: "song_name", Type: query.Text},
+		"lyrics":   {Column: "COALESCE(text, '')", Type: query.Text},
+		"link":     {Column: "COALESCE(link, '')", Type: query.Text},
+		"year":     {Column: "EXTRACT(YEAR FROM release_date)", Type: query.Number},
+		"date":     {Column: "release_date", Type: query.Date},
+		"tag":      {Column: "tags", Type: query.Array},
+		"genre":    {Column: "tags", Type: query.Array},
+		"lang":     {Column: "COALESCE(language, '')", Type: query.Keyword},
+		"language": {Column: "COALESCE(language, '')", Type: query.Keyword},
+	},
+	Default: []string{"artist", "song", "lyrics"},
+}
+
+// ValidateSongQuery parses a q expression and checks it against the songs
+// schema. The returned error is a *query.Error carrying the offending
+// position.
+func ValidateSongQuery(q string) error {
+	node, err := query.Parse(q)
+	if err != nil {
+		return err
+	}
+	return songQuerySchema.Validate(node)
+}
+
+type SongFilter struct {
+	Artist      string `json:"artist,omitempty"`
+	Group       string `json:"group_name,omitempty"`
+	Song        string `json:"song_name,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Decade      int    `json:"decade,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Language    string `json:"lang,omitempty"`
+	HasLyrics   *bool  `json:"has_lyrics,omitempty"`
+	Clean       bool   `json:"clean,omitempty"`
+
+	// Query is a q search expression; saved searches keep it alongside the
+	// filter rather than inside it.
+	Query string `json:"-"`
+
+	// CreatedAfter restricts the result to songs added after the given time.
+	CreatedAfter *time.Time `json:"-"`
+
+	// AddedAfterID restricts the result to songs with a greater id, that
+	// is, stored after the song it names.
+	AddedAfterID int `json:"-"`
+
+	// withoutFacet names a facet whose q terms are left out, see without.
+	withoutFacet string
+}
+
+// Page selects a window of an id-ordered song listing. AfterID and BeforeID
+// switch to keyset pagination; otherwise Limit and Offset are used as-is.
+type Page struct {
+	Limit    int
+	Offset   int
+	AfterID  int
+	BeforeID int
+}
+
+// where renders the filter as a SQL condition whose positional arguments
+// are numbered from argIndex.
+func (f SongFilter) where(argIndex int) (string, []interface{}, error) {
+	clause := "1=1"
+	var args []interface{}
+
+	if f.Artist != "" {
+		clause += " AND lower(group_name) = lower($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, f.Artist)
+		argIndex++
+	}
+
+	if f.Group != "" {
+		clause += " AND group_name ILIKE $" + strconv.Itoa(argIndex)
+		args = append(args, "%"+f.Group+"%")
+		argIndex++
+	}
+
+	if f.Song != "" {
+		clause += " AND song_name ILIKE $" + strconv.Itoa(argIndex)
+		args = append(args, "%"+f.Song+"%")
+		argIndex++
+	}
+
+	if f.ReleaseDate != "" {
+		clause += " AND release_date = $" + strconv.Itoa(argIndex)
+		args = append(args, f.ReleaseDate)
+		argIndex++
+	}
+
+	if f.Decade != 0 {
+		clause += " AND EXTRACT(YEAR FROM release_date) BETWEEN $" + strconv.Itoa(argIndex) + " AND $" + strconv.Itoa(argIndex+1)
+		args = append(args, f.Decade, f.Decade+9)
+		argIndex += 2
+	}
+
+	if f.Tag != "" {
+		clause += " AND $" + strconv.Itoa(argIndex) + " = ANY(tags)"
+		args = append(args, f.Tag)
+		argIndex++
+	}
+
+	if f.Language != "" {
+		clause += " AND language = $" + strconv.Itoa(argIndex)
+		args = append(args, f.Language)
+		argIndex++
+	}
+
+	if f.HasLyrics != nil {
+		clause += " AND (COALESCE(text, '') <> '') = $" + strconv.Itoa(argIndex)
+		args = append(args, *f.HasLyrics)
+		argIndex++
+	}
+
+	if f.Clean {
+		clause += " AND NOT explicit"
+	}
+
+	if f.CreatedAfter != nil {
+		clause += " AND created_at > $" + strconv.Itoa(argIndex)
+		args = append(args, *f.CreatedAfter)
+		argIndex++
+	}
+
+	if f.AddedAfterID != 0 {
+		clause += " AND id > $" + strconv.Itoa(argIndex)
+		args = append(args, f.AddedAfterID)
+		argIndex++
+	}
+
+	if f.Query != "" {
+		node, err := query.Parse(f.Query)
+		if err != nil {
+			return "", nil, domain.Validation(err)
+		}
+		// Validate the whole expression before dropping any of it.
+		if err := songQuerySchema.Validate(node); err != nil {
+			return "", nil, domain.Validation(err)
+		}
+		if node = query.Without(node, facetQueryFields[f.withoutFacet]...); node != nil {
+			expr, queryArgs, err := songQuerySchema.Compile(node, argIndex)
+			if err != nil {
+				return "", nil, domain.Validation(err)
+			}
+			clause += " AND " + expr
+			args = append(args, queryArgs...)
+		}
+	}
+
+	return clause, args, nil
+}
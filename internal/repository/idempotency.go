@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"music-service/pkg/logger"
+	"time"
+
+	"log/slog"
+)
+
+// IdempotentResponse is a response stored for replay. Header holds the
+// response headers worth replaying, such as Content-Type and Location.
+type IdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// IdempotencyRecord is a key claimed by an earlier request. Response is nil
+// while that request is still running.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
+
+type IdempotencyRepository interface {
+	ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, expiresAt, staleBefore time.Time) (*IdempotencyRecord, error)
+	RefreshIdempotencyKey(ctx context.Context, key string) error
+	CompleteIdempotencyKey(ctx context.Context, key string, response IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error)
+}
+
+type idempotencyRepository struct {
+	db     *sql.DB
+	logger *logger.Loggers
+}
+
+func NewIdempotencyRepository(db *sql.DB, logger *logger.Loggers) IdempotencyRepository {
+	return &idempotencyRepository{db: db, logger: logger}
+}
+
+// ClaimIdempotencyKey claims key for a request with the given fingerprint
+// and returns nil, or returns the record of the request that holds it. An
+// expired key, or one whose unfinished request was last refreshed before
+// staleBefore, is taken over.
+func (r *idempotencyRepository) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, expiresAt, staleBefore time.Time) (*IdempotencyRecord, error) {
+	r.logger.DebugLogger.Debug("Entering ClaimIdempotencyKey", slog.String("key", key))
+
+	claim := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = '{}', body = NULL, created_at = now(), refreshed_at = now(),
+				expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now() OR (idempotency_keys.status IS NULL AND idempotency_keys.refreshed_at < $4)
+		RETURNING key
+	`
+	lookup := "SELECT fingerprint, status, headers, body FROM idempotency_keys WHERE key = $1"
+
+	// The holder may finish or be taken over between the two statements;
+	// a second round settles it.
+	for attempt := 0; attempt < 2; attempt++ {
+		var claimed string
+		err := r.db.QueryRowContext(ctx, claim, key, fingerprint, expiresAt, staleBefore).Scan(&claimed)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.ErrorLogger.Error("Error claiming idempotency key", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+
+		var record IdempotencyRecord
+		var status sql.NullInt64
+		var headers, body []byte
+		err = r.db.QueryRowContext(ctx, lookup, key).Scan(&record.Fingerprint, &status, &headers, &body)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			r.logger.ErrorLogger.Error("Error fetching idempotency key", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+
+		if status.Valid {
+			record.Response = &IdempotentResponse{Status: int(status.Int64), Body: body}
+			if err := json.Unmarshal(headers, &record.Response.Header); err != nil {
+				return nil, err
+			}
+		}
+		return &record, nil
+	}
+	return &IdempotencyRecord{Fingerprint: fingerprint}, nil
+}
+
+// RefreshIdempotencyKey marks the request holding key as still running.
+func (r *idempotencyRepository) RefreshIdempotencyKey(ctx context.Context, key string) error {
+	query := "UPDATE idempotency_keys SET refreshed_at = now() WHERE key = $1 AND status IS NULL"
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		r.logger.ErrorLogger.Error("Error refreshing idempotency key", slog.String("key", key), slog.Any("error", err))
+		return dbError(err)
+	}
+	return nil
+}
+
+// CompleteIdempotencyKey stores the response to the request holding key.
+func (r *idempotencyRepository) CompleteIdempotencyKey(ctx context.Context, key string, response IdempotentResponse) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE idempotency_keys SET status = $2, headers = $3, body = $4 WHERE key = $1"
+	if _, err := r.db.ExecContext(ctx, query, key, response.Status, string(headers), response.Body); err != nil {
+		r.logger.ErrorLogger.Error("Error storing idempotent response", slog.String("key", key), slog.Any("error", err))
+		return dbError(err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey releases key, so a retry runs the request again.
+func (r *idempotencyRepository) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1", key); err != nil {
+		r.logger.ErrorLogger.Error("Error deleting idempotency key", slog.String("key", key), slog.Any("error", err))
+		return dbError(err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes expired keys and returns how many
+// there were.
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()")
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error deleting expired idempotency keys", slog.Any("error", err))
+		return 0, dbError(err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/logger"
+	"slices"
+	"strings"
+
+	"log/slog"
+)
+
+// ImportStatus is the outcome of importing one song.
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportUpdated   ImportStatus = "updated"
+	ImportDuplicate ImportStatus = "skipped_duplicate"
+	ImportFailed    ImportStatus = "error"
+)
+
+// ImportOptions control how a SongImporter writes songs. Without Upsert a
+// song whose artist and title already exist, compared case-insensitively,
+// is skipped; with it the stored song is replaced. DryRun only reports
+// what would happen. Prepare is called for every song about to be written,
+// with the stored song it replaces or nil, and may reject it.
+type ImportOptions struct {
+	Upsert  bool
+	DryRun  bool
+	Prepare func(song, previous *domain.Song) error
+}
+
+// ImportResult reports what became of one song of a batch. Err is set for
+// ImportFailed.
+type ImportResult struct {
+	Status ImportStatus
+	Song   domain.Song
+	Err    error
+}
+
+// SongImporter writes imported songs batch by batch. Each batch is committed
+// on its own, and a failing song only rolls back itself. A dry run writes
+// nothing and takes no locks; it remembers the songs it would have created,
+// so duplicates between batches are still found.
+type SongImporter interface {
+	ImportBatch(ctx context.Context, songs []domain.Song) ([]ImportResult, error)
+	Close() error
+}
+
+// songIdentityMatch selects the song with the artist and title given as $1
+// and $2, as the songs_identity_idx index compares them. The index is not
+// unique, so the importer serializes writers of one identity itself with
+// songIdentityLock.
+const songIdentityMatch = "lower(group_name) = lower($1) AND lower(song_name) = lower($2)"
+
+// songIdentityLock takes a transaction-scoped advisory lock on the artist
+// and title given as $1 and $2, so concurrent imports of the same song
+// cannot both find it missing and insert it.
+const songIdentityLock = "SELECT pg_advisory_xact_lock(hashtext(lower($1) || chr(0) || lower($2)))"
+
+type songImporter struct {
+	db     *sql.DB
+	opts   ImportOptions
+	seen   map[string]bool
+	logger *logger.Loggers
+}
+
+// BeginImport starts an import.
+func (r *songRepository) BeginImport(ctx context.Context, opts ImportOptions) (SongImporter, error) {
+	importer := &songImporter{db: r.db, opts: opts, logger: r.logger}
+	if opts.DryRun {
+		importer.seen = make(map[string]bool)
+	}
+	return importer, nil
+}
+
+// ImportBatch writes songs in one transaction and reports on each, in
+// order. It fails as a whole only if the database does.
+func (i *songImporter) ImportBatch(ctx context.Context, songs []domain.Song) ([]ImportResult, error) {
+	i.logger.DebugLogger.Debug("Entering ImportBatch", slog.Int("songs", len(songs)), slog.Bool("upsert", i.opts.Upsert), slog.Bool("dryRun", i.opts.DryRun))
+
+	if i.opts.DryRun {
+		return i.previewBatch(ctx, songs)
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	results, err := i.importBatch(ctx, tx, songs)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		i.logger.ErrorLogger.Error("Error committing import batch", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	i.logger.InfoLogger.Info("Successfully imported batch", slog.Int("songs", len(songs)))
+	return results, nil
+}
+
+func (i *songImporter) importBatch(ctx context.Context, tx *sql.Tx, songs []domain.Song) ([]ImportResult, error) {
+	results := make([]ImportResult, len(songs))
+	for n, song := range songs {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_song"); err != nil {
+			return nil, dbError(err)
+		}
+
+		status, err := i.importSong(ctx, tx, &song)
+		if err != nil {
+			err = dbError(err)
+			if errors.Is(err, domain.ErrUnavailable) || ctx.Err() != nil {
+				return nil, err
+			}
+			i.logger.ErrorLogger.Error("Error importing song", slog.String("group", song.Group), slog.String("song", song.Song), slog.Any("error", err))
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_song"); err != nil {
+				return nil, dbError(err)
+			}
+			results[n] = ImportResult{Status: ImportFailed, Song: song, Err: err}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_song"); err != nil {
+			return nil, dbError(err)
+		}
+		results[n] = ImportResult{Status: status, Song: song}
+	}
+	return results, nil
+}
+
+// importSong writes one song, inserting it or replacing the stored song
+// with the same artist and title.
+func (i *songImporter) importSong(ctx context.Context, tx *sql.Tx, song *domain.Song) (ImportStatus, error) {
+	if _, err := tx.ExecContext(ctx, songIdentityLock, song.Group, song.Song); err != nil {
+		return "", err
+	}
+
+	query := "SELECT " + songColumns + " FROM songs WHERE " + songIdentityMatch + " ORDER BY id LIMIT 1 FOR UPDATE"
+	existing, err := scanSong(tx.QueryRowContext(ctx, query, song.Group, song.Song))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if err := i.opts.Prepare(song, nil); err != nil {
+			return "", err
+		}
+		if err := insertSong(ctx, tx, song); err != nil {
+			return "", err
+		}
+		return ImportCreated, nil
+	case err != nil:
+		return "", err
+	case !i.opts.Upsert:
+		*song = existing
+		return ImportDuplicate, nil
+	}
+
+	previous := existing
+	previous.Tags = slices.Clone(existing.Tags)
+	if err := i.opts.Prepare(song, &previous); err != nil {
+		return "", err
+	}
+	song.ID, song.Version, song.CreatedAt = existing.ID, existing.Version, existing.CreatedAt
+	if err := updateSongColumns(ctx, tx, existing, song); err != nil {
+		return "", err
+	}
+	return ImportUpdated, nil
+}
+
+// previewBatch reports what ImportBatch would do with songs without
+// writing them.
+func (i *songImporter) previewBatch(ctx context.Context, songs []domain.Song) ([]ImportResult, error) {
+	results := make([]ImportResult, len(songs))
+	for n, song := range songs {
+		status, err := i.previewSong(ctx, &song)
+		if err != nil {
+			err = dbError(err)
+			if errors.Is(err, domain.ErrUnavailable) || ctx.Err() != nil {
+				return nil, err
+			}
+			results[n] = ImportResult{Status: ImportFailed, Song: song, Err: err}
+			continue
+		}
+		results[n] = ImportResult{Status: status, Song: song}
+	}
+	return results, nil
+}
+
+// previewSong works out what importSong would do with song, counting the
+// songs earlier rows of the dry run would have created as stored.
+func (i *songImporter) previewSong(ctx context.Context, song *domain.Song) (ImportStatus, error) {
+	identity := strings.ToLower(song.Group) + "\x00" + strings.ToLower(song.Song)
+
+	query := "SELECT " + songColumns + " FROM songs WHERE " + songIdentityMatch + " ORDER BY id LIMIT 1"
+	existing, err := scanSong(i.db.QueryRowContext(ctx, query, song.Group, song.Song))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if i.seen[identity] && !i.opts.Upsert {
+			return ImportDuplicate, nil
+		}
+		if err := i.opts.Prepare(song, nil); err != nil {
+			return "", err
+		}
+		if i.seen[identity] {
+			return ImportUpdated, nil
+		}
+		i.seen[identity] = true
+		return ImportCreated, nil
+	case err != nil:
+		return "", err
+	case !i.opts.Upsert:
+		*song = existing
+		return ImportDuplicate, nil
+	}
+
+	previous := existing
+	previous.Tags = slices.Clone(existing.Tags)
+	if err := i.opts.Prepare(song, &previous); err != nil {
+		return "", err
+	}
+	song.ID, song.Version, song.CreatedAt = existing.ID, existing.Version, existing.CreatedAt
+	return ImportUpdated, nil
+}
+
+// Close ends the import.
+func (i *songImporter) Close() error {
+	return nil
+}
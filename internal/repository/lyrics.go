@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"music-service/pkg/lyrics"
+	"strings"
+
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+// LyricsPage is one page of a song's stanzas. Total counts all stanzas or
+// all lines of the song, depending on the unit the page was requested in.
+// Structure is the section pattern of the whole song, e.g. "A B C B", and
+// Language the song's language code.
+type LyricsPage struct {
+	Stanzas   []lyrics.Stanza
+	Total     int
+	Structure string
+	Language  string
+}
+
+// writeVerses replaces the stored stanzas of a song with those parsed from
+// text, along with their detected sections, and re-resolves the song's
+// annotation anchors against them. It must run in the same transaction as
+// the write to songs.text, so the lyrics, stanzas and anchors never
+// disagree.
+func writeVerses(ctx context.Context, tx *sql.Tx, songID int, text string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM song_verses WHERE song_id = $1", songID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO song_verses (song_id, position, label, start_line, lines, section, role, repeat_of, hash)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), $8, $9)
+	`
+	stanzas := lyrics.Parse(text)
+	lyrics.DetectStructure(stanzas)
+	for _, stanza := range stanzas {
+		if _, err := tx.ExecContext(ctx, query, songID, stanza.Index, stanza.Label, stanza.StartLine, pq.Array(stanza.Lines), stanza.Section, stanza.Role, stanza.RepeatOf, stanza.ContentHash()); err != nil {
+			return err
+		}
+	}
+
+	return reanchorAnnotations(ctx, tx, songID, stanzas)
+}
+
+// GetSongLyricsPaginated reads a page of a song's stored stanzas. The
+// counts and the page are read in one REPEATABLE READ transaction, so they
+// agree even while the lyrics are being edited. A song with lyrics but no
+// stored stanzas, such as one added before song_verses was populated, is
+// paginated from its parsed text instead.
+func (r *songRepository) GetSongLyricsPaginated(ctx context.Context, songID int, unit lyrics.Unit, limit, offset int) (*LyricsPage, error) {
+	r.logger.DebugLogger.Debug("Entering GetSongLyricsPaginated", slog.Int("songID", songID), slog.String("unit", string(unit)))
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, dbError(err)
+	}
+	// The transaction only reads, so it is rolled back rather than committed.
+	defer tx.Rollback()
+
+	countQuery := `
+		SELECT COUNT(v.position), COALESCE(SUM(cardinality(v.lines)), 0),
+			COALESCE(string_agg(v.section, ' ' ORDER BY v.position), ''), COALESCE(s.language, ''),
+			CASE WHEN COUNT(v.position) = 0 THEN COALESCE(s.text, '') ELSE '' END
+		FROM songs s LEFT JOIN song_verses v ON v.song_id = s.id
+		WHERE s.id = $1
+		GROUP BY s.id
+	`
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", countQuery), slog.Int("songID", songID))
+
+	var stanzaCount, lineCount int
+	var structure, language, text string
+	err = tx.QueryRowContext(ctx, countQuery, songID).Scan(&stanzaCount, &lineCount, &structure, &language, &text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error counting song verses", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	if stanzaCount == 0 && text != "" {
+		r.logger.InfoLogger.Info("Song has no stored stanzas; paginating its text", slog.Int("songID", songID))
+		return parsedLyricsPage(text, language, unit, limit, offset), nil
+	}
+
+	var query string
+	page := &LyricsPage{Stanzas: []lyrics.Stanza{}, Structure: structure, Language: language}
+	if unit == lyrics.UnitLine {
+		page.Total = lineCount
+		query = `
+			SELECT v.position, COALESCE(v.label, ''), COALESCE(v.section, ''), COALESCE(v.role, ''), v.repeat_of, COALESCE(v.hash, ''), v.start_line + l.n - 1, l.line
+			FROM song_verses v
+			CROSS JOIN LATERAL unnest(v.lines) WITH ORDINALITY AS l(line, n)
+			WHERE v.song_id = $1
+			ORDER BY v.position, l.n
+			LIMIT $2 OFFSET $3
+		`
+	} else {
+		page.Total = stanzaCount
+		query = `
+			SELECT p.position, COALESCE(p.label, ''), COALESCE(p.section, ''), COALESCE(p.role, ''), p.repeat_of, COALESCE(p.hash, ''), p.start_line, l.line
+			FROM (
+				SELECT position, label, section, role, repeat_of, hash, start_line, lines
+				FROM song_verses
+				WHERE song_id = $1
+				ORDER BY position
+				LIMIT $2 OFFSET $3
+			) p
+			CROSS JOIN LATERAL unnest(p.lines) WITH ORDINALITY AS l(line, n)
+			ORDER BY p.position, l.n
+		`
+	}
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Int("songID", songID))
+
+	rows, err := tx.QueryContext(ctx, query, songID, limit, offset)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching song verses", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	// Both queries return one row per line; fold consecutive lines of the
+	// same stanza back together.
+	for rows.Next() {
+		var position, lineNo int
+		var label, section, role, hash, line string
+		var repeatOf sql.NullInt64
+		if err := rows.Scan(&position, &label, &section, &role, &repeatOf, &hash, &lineNo, &line); err != nil {
+			r.logger.ErrorLogger.Error("Error scanning song verse row", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+
+		n := len(page.Stanzas)
+		if n == 0 || page.Stanzas[n-1].Index != position {
+			stanza := lyrics.Stanza{Index: position, Label: label, StartLine: lineNo, Section: section, Role: role, Hash: hash}
+			if repeatOf.Valid {
+				first := int(repeatOf.Int64)
+				stanza.RepeatOf = &first
+			}
+			page.Stanzas = append(page.Stanzas, stanza)
+			n++
+		}
+		page.Stanzas[n-1].Lines = append(page.Stanzas[n-1].Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorLogger.Error("Error iterating over song verse rows", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully fetched lyrics for song", slog.Int("songID", songID), slog.Int("stanzas", len(page.Stanzas)))
+	return page, nil
+}
+
+// parsedLyricsPage builds the page GetSongLyricsPaginated would read from
+// song_verses by parsing the song's text, as writeVerses would store it.
+func parsedLyricsPage(text, language string, unit lyrics.Unit, limit, offset int) *LyricsPage {
+	stanzas := lyrics.Parse(text)
+	lyrics.DetectStructure(stanzas)
+
+	sections := make([]string, 0, len(stanzas))
+	for i := range stanzas {
+		stanzas[i].Hash = stanzas[i].ContentHash()
+		if stanzas[i].Section != "" {
+			sections = append(sections, stanzas[i].Section)
+		}
+	}
+
+	page := &LyricsPage{Stanzas: []lyrics.Stanza{}, Total: len(stanzas), Structure: strings.Join(sections, " "), Language: language}
+	if unit == lyrics.UnitLine {
+		page.Total = lyrics.LineCount(stanzas)
+	}
+	page.Stanzas = append(page.Stanzas, lyrics.Paginate(stanzas, unit, limit, offset)...)
+	return page
+}
+
+func (r *songRepository) GetSongIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM songs ORDER BY id")
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching song IDs", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// RebuildVerses re-parses a song's stored lyrics into song_verses.
+func (r *songRepository) RebuildVerses(ctx context.Context, songID int) error {
+	r.logger.DebugLogger.Debug("Entering RebuildVerses", slog.Int("songID", songID))
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		var text string
+		err := tx.QueryRowContext(ctx, "SELECT COALESCE(text, '') FROM songs WHERE id = $1 FOR UPDATE", songID).Scan(&text)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSongNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeVerses(ctx, tx, songID, text)
+	})
+}
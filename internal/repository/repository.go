@@ -3,27 +3,105 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"music-service/internal/domain"
+	"music-service/pkg/explicit"
 	"music-service/pkg/logger"
+	"music-service/pkg/lrc"
+	"music-service/pkg/lyrics"
+	"slices"
 	"strconv"
 	"strings"
 
 	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrSongNotFound = domain.NotFound(errors.New("song not found"))
+	// ErrSongModified is returned when a write expected a different version
+	// of the song than the stored one.
+	ErrSongModified = domain.Conflict(errors.New("song was modified by someone else"))
 )
 
 type SongRepository interface {
-	GetSongs(ctx context.Context, filter SongFilter, limit, offset int) ([]domain.Song, error)
-	GetSongLyricsPaginated(ctx context.Context, songID int, limit, offset int) ([]string, error)
-	DeleteSong(ctx context.Context, songID int) error
-	UpdateSong(ctx context.Context, song domain.Song) error
-	AddSong(ctx context.Context, song domain.Song) error
+	GetSongs(ctx context.Context, filter SongFilter, page Page) ([]domain.Song, error)
+	ListSongs(ctx context.Context, list SongListQuery) (*SongList, error)
+	GetSongLyricsPaginated(ctx context.Context, songID int, unit lyrics.Unit, limit, offset int) (*LyricsPage, error)
+	DeleteSong(ctx context.Context, songID, version int) error
+	ModifySong(ctx context.Context, songID int, fn func(song *domain.Song) error) (*domain.Song, error)
+	DeleteSongsWhere(ctx context.Context, filter SongFilter, opts BulkOptions) (*BulkResult, error)
+	ModifySongsWhere(ctx context.Context, filter SongFilter, opts BulkOptions, fn func(song *domain.Song) error) (*BulkResult, error)
+	AddSong(ctx context.Context, song domain.Song) (*domain.Song, error)
+	BeginImport(ctx context.Context, opts ImportOptions) (SongImporter, error)
 	GetSongByID(ctx context.Context, songID int) (*domain.Song, error)
+	GetSongIDs(ctx context.Context) ([]int, error)
+	ExportSongs(ctx context.Context, filter SongFilter, fn func(song domain.Song) error) error
+	RebuildVerses(ctx context.Context, songID int) error
+	SaveSyncedLyrics(ctx context.Context, songID int, lines []lrc.Line) error
+	GetSyncedLyrics(ctx context.Context, songID int) ([]lrc.Line, error)
+	DeleteSyncedLyrics(ctx context.Context, songID int) error
+}
+
+// songColumns lists the columns scanned by scanSong, in order.
+const songColumns = "id, group_name, song_name, release_date, text, COALESCE(raw_text, text), link, tags, COALESCE(language, ''), COALESCE(language_confidence, 0), COALESCE(language_source, ''), explicit, COALESCE(explicit_source, ''), explicit_hits, created_at, version"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-type SongFilter struct {
-	Group       string
-	Song        string
-	ReleaseDate string
+func scanSong(row rowScanner) (domain.Song, error) {
+	var song domain.Song
+	var hits []byte
+	err := row.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.RawText, &song.Link, pq.Array(&song.Tags),
+		&song.Language, &song.LanguageConfidence, &song.LanguageSource, &song.Explicit, &song.ExplicitSource, &hits, &song.CreatedAt, &song.Version)
+	if err != nil {
+		return song, err
+	}
+	return song, json.Unmarshal(hits, &song.ExplicitHits)
+}
+
+// hitsArg encodes explicit hits for the explicit_hits JSONB column.
+func hitsArg(hits []explicit.Hit) (interface{}, error) {
+	if hits == nil {
+		hits = []explicit.Hit{}
+	}
+	data, err := json.Marshal(hits)
+	return string(data), err
+}
+
+// tagsArg makes sure an unset tag list is stored as an empty array rather
+// than NULL.
+func tagsArg(tags []string) interface{} {
+	if tags == nil {
+		tags = []string{}
+	}
+	return pq.Array(tags)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	queryRower
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// songExists returns ErrSongNotFound unless a song with the given ID exists.
+func songExists(ctx context.Context, q queryRower, songID int) error {
+	var exists bool
+	if err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)", songID).Scan(&exists); err != nil {
+		return dbError(err)
+	}
+	if !exists {
+		return ErrSongNotFound
+	}
+	return nil
 }
 
 type songRepository struct {
@@ -35,154 +113,339 @@ func NewSongRepository(db *sql.DB, logger *logger.Loggers) SongRepository {
 	return &songRepository{db: db, logger: logger}
 }
 
-func (r *songRepository) GetSongs(ctx context.Context, filter SongFilter, limit, offset int) ([]domain.Song, error) {
-	r.logger.DebugLogger.Debug("Entering GetSongs", slog.Any("filter", filter))
+// withTx runs fn in a transaction, committing only if fn succeeds.
+func (r *songRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
 
-	query := "SELECT id, group_name, song_name, release_date, text, link FROM songs WHERE 1=1"
-	var args []interface{}
-	argIndex := 1
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return dbError(err)
+	}
+
+	return dbError(tx.Commit())
+}
+
+func (r *songRepository) GetSongs(ctx context.Context, filter SongFilter, page Page) ([]domain.Song, error) {
+	return r.getSongs(ctx, r.db, filter, page)
+}
+
+// SongListQuery asks ListSongs for a page of songs and, optionally, the
+// number of matching songs and facet counts.
+type SongListQuery struct {
+	Filter SongFilter
+	Page   Page
+	Total  bool
+	Facets []FacetRequest
+}
+
+// SongList is a page of songs with the counts read alongside it. Total and
+// Facets are only set when asked for.
+type SongList struct {
+	Songs  []domain.Song
+	Total  *int
+	Facets map[string][]FacetBucket
+}
 
-	if filter.Group != "" {
-		query += " AND group_name ILIKE $" + strconv.Itoa(argIndex)
-		args = append(args, "%"+filter.Group+"%")
-		argIndex++
+// ListSongs reads a page of songs together with the requested counts. When
+// there are counts to read, everything is read in one REPEATABLE READ
+// transaction, so the page, total and facets describe the same snapshot.
+// That takes a round trip per part rather than a single query: the page,
+// the total and the facet buckets have different shapes, and folding them
+// into one UNION ALL would mean encoding songs as facet-like rows. The
+// facets themselves are still read in one query.
+func (r *songRepository) ListSongs(ctx context.Context, list SongListQuery) (*SongList, error) {
+	if !list.Total && len(list.Facets) == 0 {
+		songs, err := r.getSongs(ctx, r.db, list.Filter, list.Page)
+		if err != nil {
+			return nil, err
+		}
+		return &SongList{Songs: songs}, nil
 	}
 
-	if filter.Song != "" {
-		query += " AND song_name ILIKE $" + strconv.Itoa(argIndex)
-		args = append(args, "%"+filter.Song+"%")
-		argIndex++
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, dbError(err)
 	}
+	// The transaction only reads, so it is rolled back rather than committed.
+	defer tx.Rollback()
 
-	if filter.ReleaseDate != "" {
-		query += " AND release_date = $" + strconv.Itoa(argIndex)
-		args = append(args, filter.ReleaseDate)
-		argIndex++
+	result := &SongList{}
+	if result.Songs, err = r.getSongs(ctx, tx, list.Filter, list.Page); err != nil {
+		return nil, err
 	}
+	if list.Total {
+		total, err := r.countSongs(ctx, tx, list.Filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+	if len(list.Facets) > 0 {
+		if result.Facets, err = r.getFacets(ctx, tx, list.Filter, list.Facets); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r *songRepository) getSongs(ctx context.Context, q querier, filter SongFilter, page Page) ([]domain.Song, error) {
+	r.logger.DebugLogger.Debug("Entering GetSongs", slog.Any("filter", filter), slog.Any("page", page))
 
-	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
-	args = append(args, limit, offset)
+	where, args, err := filter.where(1)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Invalid song filter", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	query := "SELECT " + songColumns + " FROM songs WHERE " + where
+	argIndex := len(args) + 1
+
+	switch {
+	case page.AfterID > 0:
+		query += " AND id > $" + strconv.Itoa(argIndex) + " ORDER BY id ASC LIMIT $" + strconv.Itoa(argIndex+1)
+		args = append(args, page.AfterID, page.Limit)
+	case page.BeforeID > 0:
+		query += " AND id < $" + strconv.Itoa(argIndex) + " ORDER BY id DESC LIMIT $" + strconv.Itoa(argIndex+1)
+		args = append(args, page.BeforeID, page.Limit)
+	default:
+		query += " ORDER BY id ASC LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+		args = append(args, page.Limit, page.Offset)
+	}
 
 	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("args", args))
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.ErrorLogger.Error("Error executing GetSongs query", slog.Any("error", err))
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 
 	var songs []domain.Song
 	for rows.Next() {
-		var song domain.Song
-		if err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
+		song, err := scanSong(rows)
+		if err != nil {
 			r.logger.ErrorLogger.Error("Error scanning song row", slog.Any("error", err))
-			return nil, err
+			return nil, dbError(err)
 		}
 		songs = append(songs, song)
 	}
 
 	if err := rows.Err(); err != nil {
 		r.logger.ErrorLogger.Error("Error iterating over song rows", slog.Any("error", err))
-		return nil, err
+		return nil, dbError(err)
+	}
+
+	// Backward pages are read in descending order; hand them back ascending
+	// so callers always see the same ordering.
+	if page.BeforeID > 0 {
+		slices.Reverse(songs)
 	}
 
 	r.logger.InfoLogger.Info("Successfully fetched songs", slog.Int("count", len(songs)))
 	return songs, nil
 }
 
-func (r *songRepository) GetSongLyricsPaginated(ctx context.Context, songID int, limit, offset int) ([]string, error) {
-	r.logger.DebugLogger.Debug("Entering GetSongLyricsPaginated", slog.Int("songID", songID))
-
-	query := "SELECT text FROM songs WHERE id = $1"
-	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Int("songID", songID))
+func (r *songRepository) countSongs(ctx context.Context, q queryRower, filter SongFilter) (int, error) {
+	r.logger.DebugLogger.Debug("Entering CountSongs", slog.Any("filter", filter))
 
-	row := r.db.QueryRowContext(ctx, query, songID)
-
-	var lyrics string
-	if err := row.Scan(&lyrics); err != nil {
-		r.logger.ErrorLogger.Error("Error fetching song lyrics", slog.Any("error", err))
-		return nil, err
-	}
-
-	verses := strings.Split(lyrics, "\n")
-	start := offset
-	end := offset + limit
-
-	if start >= len(verses) {
-		return nil, nil // no more verses to return
+	where, args, err := filter.where(1)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Invalid song filter", slog.Any("error", err))
+		return 0, dbError(err)
 	}
+	query := "SELECT COUNT(*) FROM songs WHERE " + where
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("args", args))
 
-	if end > len(verses) {
-		end = len(verses) // don’t go out of bounds
+	var total int
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		r.logger.ErrorLogger.Error("Error counting songs", slog.Any("error", err))
+		return 0, dbError(err)
 	}
 
-	r.logger.InfoLogger.Info("Successfully fetched lyrics for song", slog.Int("songID", songID), slog.Any("verses", verses[start:end]))
-	return verses[start:end], nil
+	return total, nil
 }
 
-func (r *songRepository) DeleteSong(ctx context.Context, songID int) error {
-	r.logger.DebugLogger.Debug("Entering DeleteSong", slog.Int("songID", songID))
+// DeleteSong deletes a song. A non-zero version must match the stored one,
+// or ErrSongModified is returned.
+func (r *songRepository) DeleteSong(ctx context.Context, songID, version int) error {
+	r.logger.DebugLogger.Debug("Entering DeleteSong", slog.Int("songID", songID), slog.Int("version", version))
 
-	query := "DELETE FROM songs WHERE id = $1"
+	query := "DELETE FROM songs WHERE id = $1 AND ($2 = 0 OR version = $2)"
 	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Int("songID", songID))
 
-	_, err := r.db.ExecContext(ctx, query, songID)
+	res, err := r.db.ExecContext(ctx, query, songID, version)
 	if err != nil {
 		r.logger.ErrorLogger.Error("Error deleting song", slog.Int("songID", songID), slog.Any("error", err))
-		return err
+		return dbError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if affected == 0 {
+		if err := songExists(ctx, r.db, songID); err != nil {
+			return err
+		}
+		return ErrSongModified
 	}
 
 	r.logger.InfoLogger.Info("Successfully deleted song", slog.Int("songID", songID))
 	return nil
 }
 
-func (r *songRepository) UpdateSong(ctx context.Context, song domain.Song) error {
-	r.logger.DebugLogger.Debug("Entering UpdateSong", slog.Any("song", song))
+// ModifySong locks a song, lets fn change it and writes back the columns
+// fn changed in the same transaction, so concurrent edits cannot
+// interleave. An error from fn aborts the change and is returned as-is.
+func (r *songRepository) ModifySong(ctx context.Context, songID int, fn func(song *domain.Song) error) (*domain.Song, error) {
+	r.logger.DebugLogger.Debug("Entering ModifySong", slog.Int("songID", songID))
 
-	query := `
-		UPDATE songs
-		SET group_name = $1, song_name = $2, release_date = $3, text = $4, link = $5
-		WHERE id = $6
-	`
-	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("song", song))
+	var song domain.Song
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		song, err = scanSong(tx.QueryRowContext(ctx, "SELECT "+songColumns+" FROM songs WHERE id = $1 FOR UPDATE", songID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSongNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-	_, err := r.db.ExecContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.ID)
+		previous := song
+		previous.Tags = slices.Clone(song.Tags)
+		if err := fn(&song); err != nil {
+			return err
+		}
+		song.ID, song.Version = songID, previous.Version
+		return updateSongColumns(ctx, tx, previous, &song)
+	})
 	if err != nil {
-		r.logger.ErrorLogger.Error("Error updating song", slog.Int("songID", song.ID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully modified song", slog.Int("songID", songID))
+	return &song, nil
+}
+
+// updateSongColumns writes only the columns in which song differs from
+// previous, bumping its version, and rebuilds the stanzas only if the
+// lyrics changed.
+func updateSongColumns(ctx context.Context, tx *sql.Tx, previous domain.Song, song *domain.Song) error {
+	var sets []string
+	var args []interface{}
+	set := func(assignment string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf(assignment, len(args)))
+	}
+
+	if song.Group != previous.Group {
+		set("group_name = $%d", song.Group)
+	}
+	if song.Song != previous.Song {
+		set("song_name = $%d", song.Song)
+	}
+	if !song.ReleaseDate.Equal(previous.ReleaseDate) {
+		set("release_date = $%d", song.ReleaseDate)
+	}
+	textChanged := song.Text != previous.Text
+	if textChanged || song.RawText != previous.RawText {
+		set("text = $%d", song.Text)
+		// SET expressions see the old row, so raw_text is compared with the
+		// new text's parameter rather than the text column.
+		args = append(args, song.RawText)
+		sets = append(sets, fmt.Sprintf("raw_text = NULLIF(NULLIF($%d, ''), $%d)", len(args), len(args)-1))
+	}
+	if song.Link != previous.Link {
+		set("link = $%d", song.Link)
+	}
+	if !slices.Equal(song.Tags, previous.Tags) {
+		set("tags = $%d", tagsArg(song.Tags))
+	}
+	if song.Language != previous.Language || song.LanguageConfidence != previous.LanguageConfidence || song.LanguageSource != previous.LanguageSource {
+		set("language = NULLIF($%d, '')", song.Language)
+		set("language_confidence = NULLIF($%d, 0)", song.LanguageConfidence)
+		set("language_source = NULLIF($%d, '')", song.LanguageSource)
+	}
+	if song.Explicit != previous.Explicit || song.ExplicitSource != previous.ExplicitSource || !slices.Equal(song.ExplicitHits, previous.ExplicitHits) {
+		hits, err := hitsArg(song.ExplicitHits)
+		if err != nil {
+			return err
+		}
+		set("explicit = $%d", song.Explicit)
+		set("explicit_source = NULLIF($%d, '')", song.ExplicitSource)
+		set("explicit_hits = $%d", hits)
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	args = append(args, song.ID)
+	query := "UPDATE songs SET " + strings.Join(sets, ", ") + ", version = version + 1 WHERE id = $" + strconv.Itoa(len(args)) + " RETURNING version"
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&song.Version); err != nil {
 		return err
 	}
 
-	r.logger.InfoLogger.Info("Successfully updated song", slog.Int("songID", song.ID))
-	return nil
+	if !textChanged {
+		return nil
+	}
+	return writeVerses(ctx, tx, song.ID, song.Text)
 }
 
-func (r *songRepository) AddSong(ctx context.Context, song domain.Song) error {
+// AddSong stores a new song and returns it with its ID, creation time and
+// version.
+func (r *songRepository) AddSong(ctx context.Context, song domain.Song) (*domain.Song, error) {
 	r.logger.DebugLogger.Debug("Entering AddSong", slog.Any("song", song))
 
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		return insertSong(ctx, tx, &song)
+	})
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error adding song", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully added song", slog.Int("songID", song.ID))
+	return &song, nil
+}
+
+// insertSong stores a new song and its stanzas, setting its ID, creation
+// time and version.
+func insertSong(ctx context.Context, tx *sql.Tx, song *domain.Song) error {
 	query := `
-		INSERT INTO songs (group_name, song_name, release_date, text, link)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO songs (group_name, song_name, release_date, text, link, tags, language, language_confidence, language_source, raw_text,
+			explicit, explicit_source, explicit_hits)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, ''), NULLIF(NULLIF($10, ''), $4),
+			$11, NULLIF($12, ''), $13)
+		RETURNING id, created_at, version
 	`
-	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Any("song", song))
 
-	_, err := r.db.ExecContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link)
+	hits, err := hitsArg(song.ExplicitHits)
 	if err != nil {
-		r.logger.ErrorLogger.Error("Error adding song", slog.Any("error", err))
 		return err
 	}
 
-	r.logger.InfoLogger.Info("Successfully added song", slog.Any("song", song))
-	return nil
+	if err := tx.QueryRowContext(ctx, query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, tagsArg(song.Tags),
+		song.Language, song.LanguageConfidence, song.LanguageSource, song.RawText, song.Explicit, song.ExplicitSource, hits).Scan(&song.ID, &song.CreatedAt, &song.Version); err != nil {
+		return err
+	}
+	return writeVerses(ctx, tx, song.ID, song.Text)
 }
 
 func (r *songRepository) GetSongByID(ctx context.Context, songID int) (*domain.Song, error) {
-	query := "SELECT id, group_name, song_name, release_date, text, link FROM songs WHERE id = $1"
+	query := "SELECT " + songColumns + " FROM songs WHERE id = $1"
 	row := r.db.QueryRowContext(ctx, query, songID)
 
-	var song domain.Song
-	if err := row.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
-		return nil, err
+	song, err := scanSong(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching song", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, dbError(err)
 	}
 
 	return &song, nil
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/logger"
+	"time"
+
+	"log/slog"
+)
+
+var ErrSavedSearchNotFound = domain.NotFound(errors.New("saved search not found"))
+
+type SavedSearch struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	Filter         SongFilter `json:"filter"`
+	Query          string     `json:"query,omitempty"`
+	WebhookURL     string     `json:"webhook_url,omitempty"`
+	LastCheckedAt  time.Time  `json:"last_checked_at"`
+	LastNotifiedID int        `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// SongFilter returns the filter to run for the saved search, including its
+// q expression.
+func (s SavedSearch) SongFilter() SongFilter {
+	filter := s.Filter
+	filter.Query = s.Query
+	return filter
+}
+
+type SavedSearchRepository interface {
+	CreateSavedSearch(ctx context.Context, search SavedSearch) (*SavedSearch, error)
+	GetSavedSearches(ctx context.Context) ([]SavedSearch, error)
+	GetSavedSearchByID(ctx context.Context, id int) (*SavedSearch, error)
+	GetSavedSearchesWithWebhook(ctx context.Context) ([]SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id int) error
+	MarkSavedSearchChecked(ctx context.Context, id int, checkedAt time.Time) error
+	MarkSavedSearchNotified(ctx context.Context, id, lastSongID int) error
+}
+
+const savedSearchColumns = "id, name, filter, query, COALESCE(webhook_url, ''), last_checked_at, last_notified_id, created_at"
+
+type savedSearchRepository struct {
+	db     *sql.DB
+	logger *logger.Loggers
+}
+
+func NewSavedSearchRepository(db *sql.DB, logger *logger.Loggers) SavedSearchRepository {
+	return &savedSearchRepository{db: db, logger: logger}
+}
+
+func scanSavedSearch(row rowScanner) (SavedSearch, error) {
+	var search SavedSearch
+	var filter []byte
+	if err := row.Scan(&search.ID, &search.Name, &filter, &search.Query, &search.WebhookURL, &search.LastCheckedAt, &search.LastNotifiedID, &search.CreatedAt); err != nil {
+		return search, err
+	}
+	if err := json.Unmarshal(filter, &search.Filter); err != nil {
+		return search, err
+	}
+	return search, nil
+}
+
+func (r *savedSearchRepository) CreateSavedSearch(ctx context.Context, search SavedSearch) (*SavedSearch, error) {
+	r.logger.DebugLogger.Debug("Entering CreateSavedSearch", slog.Any("search", search))
+
+	filter, err := json.Marshal(search.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only songs added after the search are notified.
+	query := `
+		INSERT INTO saved_searches (name, filter, query, webhook_url, last_notified_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), (SELECT COALESCE(max(id), 0) FROM songs))
+		RETURNING ` + savedSearchColumns
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query))
+
+	created, err := scanSavedSearch(r.db.QueryRowContext(ctx, query, search.Name, filter, search.Query, search.WebhookURL))
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error creating saved search", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully created saved search", slog.Int("id", created.ID))
+	return &created, nil
+}
+
+func (r *savedSearchRepository) GetSavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	return r.list(ctx, "SELECT "+savedSearchColumns+" FROM saved_searches ORDER BY id")
+}
+
+func (r *savedSearchRepository) GetSavedSearchesWithWebhook(ctx context.Context) ([]SavedSearch, error) {
+	return r.list(ctx, "SELECT "+savedSearchColumns+" FROM saved_searches WHERE webhook_url IS NOT NULL ORDER BY id")
+}
+
+func (r *savedSearchRepository) list(ctx context.Context, query string) ([]SavedSearch, error) {
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query))
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching saved searches", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	searches := []SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			r.logger.ErrorLogger.Error("Error scanning saved search row", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+		searches = append(searches, search)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorLogger.Error("Error iterating over saved search rows", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	return searches, nil
+}
+
+func (r *savedSearchRepository) GetSavedSearchByID(ctx context.Context, id int) (*SavedSearch, error) {
+	query := "SELECT " + savedSearchColumns + " FROM saved_searches WHERE id = $1"
+
+	search, err := scanSavedSearch(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching saved search", slog.Int("id", id), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	return &search, nil
+}
+
+func (r *savedSearchRepository) DeleteSavedSearch(ctx context.Context, id int) error {
+	return r.exec(ctx, "DELETE FROM saved_searches WHERE id = $1", id)
+}
+
+func (r *savedSearchRepository) MarkSavedSearchChecked(ctx context.Context, id int, checkedAt time.Time) error {
+	return r.exec(ctx, "UPDATE saved_searches SET last_checked_at = $2 WHERE id = $1", id, checkedAt)
+}
+
+// MarkSavedSearchNotified records the id of the last song sent to the
+// search's webhook. The watermark only moves forward.
+func (r *savedSearchRepository) MarkSavedSearchNotified(ctx context.Context, id, lastSongID int) error {
+	return r.exec(ctx, "UPDATE saved_searches SET last_notified_id = GREATEST(last_notified_id, $2) WHERE id = $1", id, lastSongID)
+}
+
+// exec runs a statement against a single saved search and reports
+// ErrSavedSearchNotFound when no row was touched.
+func (r *savedSearchRepository) exec(ctx context.Context, query string, id int, args ...interface{}) error {
+	r.logger.DebugLogger.Debug("Executing query", slog.String("query", query), slog.Int("id", id))
+
+	res, err := r.db.ExecContext(ctx, query, append([]interface{}{id}, args...)...)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error updating saved search", slog.Int("id", id), slog.Any("error", err))
+		return dbError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if affected == 0 {
+		return ErrSavedSearchNotFound
+	}
+
+	return nil
+}
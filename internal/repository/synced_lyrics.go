@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/lrc"
+	"time"
+
+	"log/slog"
+)
+
+var ErrNoSyncedLyrics = domain.NotFound(errors.New("song has no synced lyrics"))
+
+// syncedWord is the JSON form of an lrc.Word in song_synced_lines.words.
+type syncedWord struct {
+	TimeMS int64  `json:"t"`
+	Text   string `json:"w"`
+}
+
+// SaveSyncedLyrics replaces the synced lyrics of a song.
+func (r *songRepository) SaveSyncedLyrics(ctx context.Context, songID int, lines []lrc.Line) error {
+	r.logger.DebugLogger.Debug("Entering SaveSyncedLyrics", slog.Int("songID", songID), slog.Int("lines", len(lines)))
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := songExists(ctx, tx, songID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM song_synced_lines WHERE song_id = $1", songID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO song_synced_lines (song_id, position, time_ms, text, words)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		for i, line := range lines {
+			var words []byte
+			if len(line.Words) > 0 {
+				stored := make([]syncedWord, len(line.Words))
+				for j, word := range line.Words {
+					stored[j] = syncedWord{TimeMS: word.Time.Milliseconds(), Text: word.Text}
+				}
+				var err error
+				if words, err = json.Marshal(stored); err != nil {
+					return err
+				}
+			}
+
+			if _, err := tx.ExecContext(ctx, query, songID, i, line.Time.Milliseconds(), line.Text, words); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error saving synced lyrics", slog.Int("songID", songID), slog.Any("error", err))
+		return dbError(err)
+	}
+
+	r.logger.InfoLogger.Info("Successfully saved synced lyrics", slog.Int("songID", songID), slog.Int("lines", len(lines)))
+	return nil
+}
+
+// GetSyncedLyrics returns the synced lines of a song in playback order.
+func (r *songRepository) GetSyncedLyrics(ctx context.Context, songID int) ([]lrc.Line, error) {
+	r.logger.DebugLogger.Debug("Entering GetSyncedLyrics", slog.Int("songID", songID))
+
+	query := "SELECT time_ms, text, words FROM song_synced_lines WHERE song_id = $1 ORDER BY position"
+	rows, err := r.db.QueryContext(ctx, query, songID)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error fetching synced lyrics", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var lines []lrc.Line
+	for rows.Next() {
+		var timeMS int64
+		var words []byte
+		var line lrc.Line
+		if err := rows.Scan(&timeMS, &line.Text, &words); err != nil {
+			r.logger.ErrorLogger.Error("Error scanning synced lyrics row", slog.Any("error", err))
+			return nil, dbError(err)
+		}
+		line.Time = time.Duration(timeMS) * time.Millisecond
+
+		if words != nil {
+			var stored []syncedWord
+			if err := json.Unmarshal(words, &stored); err != nil {
+				return nil, err
+			}
+			for _, word := range stored {
+				line.Words = append(line.Words, lrc.Word{Time: time.Duration(word.TimeMS) * time.Millisecond, Text: word.Text})
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorLogger.Error("Error iterating over synced lyrics rows", slog.Any("error", err))
+		return nil, dbError(err)
+	}
+
+	if len(lines) == 0 {
+		if err := songExists(ctx, r.db, songID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoSyncedLyrics
+	}
+
+	return lines, nil
+}
+
+func (r *songRepository) DeleteSyncedLyrics(ctx context.Context, songID int) error {
+	r.logger.DebugLogger.Debug("Entering DeleteSyncedLyrics", slog.Int("songID", songID))
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM song_synced_lines WHERE song_id = $1", songID)
+	if err != nil {
+		r.logger.ErrorLogger.Error("Error deleting synced lyrics", slog.Int("songID", songID), slog.Any("error", err))
+		return dbError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if affected == 0 {
+		return ErrNoSyncedLyrics
+	}
+
+	return nil
+}
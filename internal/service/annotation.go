@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+	"strings"
+	"unicode/utf8"
+
+	"log/slog"
+)
+
+var ErrInvalidAnnotation = domain.Validation(errors.New("invalid annotation"))
+
+type AnnotationService interface {
+	GetAnnotations(ctx context.Context, songID int) ([]domain.Annotation, error)
+	GetAnnotation(ctx context.Context, songID, id int) (*domain.Annotation, error)
+	CreateAnnotation(ctx context.Context, annotation domain.Annotation) (*domain.Annotation, error)
+	DeleteAnnotation(ctx context.Context, songID, id int) error
+	VoteAnnotation(ctx context.Context, songID, id int, voter string, vote int) (*domain.Annotation, error)
+}
+
+type annotationService struct {
+	repo   repository.AnnotationRepository
+	songs  repository.SongRepository
+	logger *logger.Loggers
+}
+
+func NewAnnotationService(repo repository.AnnotationRepository, songs repository.SongRepository, logger *logger.Loggers) AnnotationService {
+	return &annotationService{repo: repo, songs: songs, logger: logger}
+}
+
+func (s *annotationService) GetAnnotations(ctx context.Context, songID int) ([]domain.Annotation, error) {
+	return s.repo.GetAnnotations(ctx, songID)
+}
+
+func (s *annotationService) GetAnnotation(ctx context.Context, songID, id int) (*domain.Annotation, error) {
+	return s.repo.GetAnnotationByID(ctx, songID, id)
+}
+
+// CreateAnnotation checks the anchor against the song's current lyrics and
+// records the text it covers, so the annotation can follow that text when
+// the lyrics are edited. An anchor with no end covers its whole line or
+// stanza.
+func (s *annotationService) CreateAnnotation(ctx context.Context, annotation domain.Annotation) (*domain.Annotation, error) {
+	s.logger.DebugLogger.Debug("Entering CreateAnnotation service", slog.Int("songID", annotation.SongID))
+
+	annotation.Author = strings.TrimSpace(annotation.Author)
+	annotation.Body = strings.TrimSpace(annotation.Body)
+	if annotation.Author == "" || annotation.Body == "" {
+		return nil, fmt.Errorf("%w: author and body are required", ErrInvalidAnnotation)
+	}
+	if utf8.RuneCountInString(annotation.Author) > maxFieldLength {
+		return nil, fmt.Errorf("%w: author must be at most %d characters", ErrInvalidAnnotation, maxFieldLength)
+	}
+
+	song, err := s.songs.GetSongByID(ctx, annotation.SongID)
+	if err != nil {
+		return nil, err
+	}
+
+	stanzas := lyrics.Parse(song.Text)
+	if annotation.Anchor.Start == 0 && annotation.Anchor.End == 0 {
+		annotation.Anchor = annotation.Anchor.Whole(stanzas)
+	}
+
+	quote, ok := annotation.Anchor.Text(stanzas)
+	if !ok {
+		return nil, fmt.Errorf("%w: anchor is outside the song's lyrics", ErrInvalidAnnotation)
+	}
+	annotation.Quote = quote
+
+	created, err := s.repo.CreateAnnotation(ctx, annotation)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error creating annotation", slog.Any("error", err))
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *annotationService) DeleteAnnotation(ctx context.Context, songID, id int) error {
+	return s.repo.DeleteAnnotation(ctx, songID, id)
+}
+
+// VoteAnnotation applies voter's up (1) or down (-1) vote. Each voter has
+// one vote per annotation; voting again replaces it.
+func (s *annotationService) VoteAnnotation(ctx context.Context, songID, id int, voter string, vote int) (*domain.Annotation, error) {
+	voter = strings.TrimSpace(voter)
+	switch {
+	case voter == "":
+		return nil, fmt.Errorf("%w: voter is required", ErrInvalidAnnotation)
+	case utf8.RuneCountInString(voter) > maxFieldLength:
+		return nil, fmt.Errorf("%w: voter must be at most %d characters", ErrInvalidAnnotation, maxFieldLength)
+	case vote != 1 && vote != -1:
+		return nil, fmt.Errorf("%w: vote must be 1 or -1", ErrInvalidAnnotation)
+	}
+	return s.repo.VoteAnnotation(ctx, songID, id, voter, vote)
+}
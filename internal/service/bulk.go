@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+// Bulk operation types.
+const (
+	BulkDelete      = "delete"
+	BulkSet         = "set"
+	BulkAddTag      = "add_tag"
+	BulkReplaceText = "replace_text"
+)
+
+// bulkSampleSize is the number of changed songs a bulk result shows.
+const bulkSampleSize = 10
+
+var (
+	ErrInvalidBulkOperation = domain.Validation(errors.New("invalid bulk operation"))
+	// ErrEmptyBulkFilter guards against changing the whole library by
+	// leaving the filter out.
+	ErrEmptyBulkFilter = domain.Validation(errors.New("bulk filter must select songs by at least one criterion"))
+)
+
+// BulkOperation is a change applied to every song a filter selects. Set
+// assigns Value to Field, which is one of group, song, release_date, link
+// and language; add_tag adds Tag; replace_text replaces matches of the
+// regular expression Pattern in the lyrics as submitted with Replacement,
+// which may refer to groups as $1, and normalizes the result again.
+type BulkOperation struct {
+	Type        string `json:"type"`
+	Field       string `json:"field,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// bulkAudit is what the audit log records about a bulk change.
+type bulkAudit struct {
+	Filter    repository.SongFilter `json:"filter"`
+	Query     string                `json:"query,omitempty"`
+	Operation BulkOperation         `json:"operation"`
+}
+
+// BulkUpdate applies op to every song matching filter in one transaction
+// and records it in the audit log. A filter matching more songs than the
+// configured maximum is refused. A dry run reports what would change
+// without changing anything.
+func (s *songService) BulkUpdate(ctx context.Context, filter repository.SongFilter, op BulkOperation, dryRun bool) (*repository.BulkResult, error) {
+	s.logger.DebugLogger.Debug("Entering BulkUpdate service", slog.Any("filter", filter), slog.Any("operation", op), slog.Bool("dryRun", dryRun))
+
+	if filter == (repository.SongFilter{}) {
+		return nil, ErrEmptyBulkFilter
+	}
+
+	opts := repository.BulkOptions{
+		DryRun:      dryRun,
+		MaxAffected: s.maxBulkAffected,
+		SampleSize:  bulkSampleSize,
+		Action:      "bulk_" + op.Type,
+		Audit:       bulkAudit{Filter: filter, Query: filter.Query, Operation: op},
+	}
+
+	var result *repository.BulkResult
+	var err error
+	if op.Type == BulkDelete {
+		result, err = s.repo.DeleteSongsWhere(ctx, filter, opts)
+	} else {
+		var change func(song *domain.Song) error
+		if change, err = s.bulkChange(op); err != nil {
+			return nil, err
+		}
+		result, err = s.repo.ModifySongsWhere(ctx, filter, opts, change)
+	}
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error applying bulk operation", slog.String("type", op.Type), slog.Any("error", err))
+		return nil, err
+	}
+
+	if !dryRun {
+		for _, id := range result.SongIDs {
+			s.stats.invalidate(id)
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully applied bulk operation", slog.String("type", op.Type), slog.Int("affected", result.Affected), slog.Bool("dryRun", dryRun))
+	return result, nil
+}
+
+// bulkChange checks op and returns the change it makes to one song.
+func (s *songService) bulkChange(op BulkOperation) (func(song *domain.Song) error, error) {
+	switch op.Type {
+	case BulkSet:
+		set, err := bulkSetter(op.Field, op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return func(song *domain.Song) error {
+			previous := *song
+			set(song)
+			if op.Field == "language" {
+				if err := resolveLanguage(song, &previous); err != nil {
+					return err
+				}
+				s.classifyExplicit(song, &previous)
+			}
+			return nil
+		}, nil
+
+	case BulkAddTag:
+		tag := strings.TrimSpace(op.Tag)
+		if tag == "" {
+			return nil, &domain.ValidationError{Fields: []domain.FieldError{{Field: "operation.tag", Code: "required", Message: "is required"}}}
+		}
+		return func(song *domain.Song) error {
+			if !slices.Contains(song.Tags, tag) {
+				song.Tags = append(song.Tags, tag)
+			}
+			return nil
+		}, nil
+
+	case BulkReplaceText:
+		if op.Pattern == "" {
+			return nil, &domain.ValidationError{Fields: []domain.FieldError{{Field: "operation.pattern", Code: "required", Message: "is required"}}}
+		}
+		pattern, err := regexp.Compile(op.Pattern)
+		if err != nil {
+			return nil, &domain.ValidationError{Fields: []domain.FieldError{{Field: "operation.pattern", Code: "invalid_format", Message: err.Error()}}}
+		}
+		return func(song *domain.Song) error {
+			text := pattern.ReplaceAllString(song.RawText, op.Replacement)
+			if text == song.RawText {
+				return nil
+			}
+			previous := *song
+			song.RawText = text
+			song.Text = s.normalizer.Normalize(text)
+			if err := resolveLanguage(song, &previous); err != nil {
+				return err
+			}
+			s.classifyExplicit(song, &previous)
+			return nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q, want %s, %s, %s or %s", ErrInvalidBulkOperation, op.Type, BulkDelete, BulkSet, BulkAddTag, BulkReplaceText)
+	}
+}
+
+// bulkSetter returns the assignment of value to field, after checking that
+// value is valid for it.
+func bulkSetter(field, value string) (func(song *domain.Song), error) {
+	var set func(song *domain.Song)
+	switch field {
+	case "group":
+		set = func(song *domain.Song) { song.Group = value }
+	case "song":
+		set = func(song *domain.Song) { song.Song = value }
+	case "link":
+		set = func(song *domain.Song) { song.Link = value }
+	case "language":
+		if value == "" {
+			return nil, &domain.ValidationError{Fields: []domain.FieldError{{Field: "operation.value", Code: "required", Message: "is required; clear a song's language with DELETE /songs/{id}/language"}}}
+		}
+		set = func(song *domain.Song) { song.Language = value }
+	case "release_date":
+		date, err := parseDate(value)
+		if err != nil {
+			return nil, &domain.ValidationError{Fields: []domain.FieldError{{Field: "operation.value", Code: "invalid_format", Message: "must be a date as YYYY-MM-DD or an RFC 3339 timestamp"}}}
+		}
+		set = func(song *domain.Song) { song.ReleaseDate = date }
+	default:
+		return nil, fmt.Errorf("%w: cannot set field %q", ErrInvalidBulkOperation, field)
+	}
+
+	// Check the value by setting it on an otherwise valid song, so only
+	// its own problems are reported.
+	probe := domain.Song{Group: "probe", Song: "probe", ReleaseDate: time.Now()}
+	set(&probe)
+	if err := validateSong(&probe); err != nil {
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			for i := range invalid.Fields {
+				invalid.Fields[i].Field = "operation.value"
+			}
+		}
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"slices"
+	"testing"
+)
+
+func TestBulkUpdateReplacesSubmittedText(t *testing.T) {
+	repo := newFakeSongRepo(
+		domain.Song{ID: 1, Group: "Muse", RawText: "“Sonne”  \nMond", Text: "\"Sonne\"\nMond"},
+		domain.Song{ID: 2, Group: "Muse", RawText: "Stern", Text: "Stern"},
+		domain.Song{ID: 3, Group: "Other", RawText: "Sonne", Text: "Sonne"},
+	)
+	svc := newTestSongService(t, repo)
+
+	op := BulkOperation{Type: BulkReplaceText, Pattern: `Sonne`, Replacement: "Licht"}
+	result, err := svc.BulkUpdate(context.Background(), repository.SongFilter{Artist: "muse"}, op, false)
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if result.Matched != 2 || !slices.Equal(result.SongIDs, []int{1}) {
+		t.Errorf("result = %+v, want 2 matched and only song 1 changed", result)
+	}
+
+	song := repo.songs[1]
+	if want := "“Licht”  \nMond"; song.RawText != want {
+		t.Errorf("RawText = %q, want %q", song.RawText, want)
+	}
+	if want := "\"Licht\"\nMond"; song.Text != want {
+		t.Errorf("Text = %q, want %q", song.Text, want)
+	}
+	if repo.songs[3].RawText != "Sonne" {
+		t.Errorf("song of another artist changed to %q", repo.songs[3].RawText)
+	}
+}
+
+func TestBulkUpdateDryRunKeepsSongs(t *testing.T) {
+	repo := newFakeSongRepo(domain.Song{ID: 1, Group: "Muse", RawText: "Sonne", Text: "Sonne"})
+	svc := newTestSongService(t, repo)
+
+	op := BulkOperation{Type: BulkAddTag, Tag: " live "}
+	result, err := svc.BulkUpdate(context.Background(), repository.SongFilter{Artist: "Muse"}, op, true)
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if result.Affected != 1 {
+		t.Errorf("affected = %d, want 1", result.Affected)
+	}
+	if song := repo.songs[1]; len(song.Tags) != 0 || song.Version != 1 {
+		t.Errorf("dry run changed the song to %+v", song)
+	}
+}
+
+func TestBulkUpdateRejectsInvalidOperations(t *testing.T) {
+	svc := newTestSongService(t, newFakeSongRepo())
+	filter := repository.SongFilter{Artist: "Muse"}
+
+	tests := []struct {
+		name   string
+		filter repository.SongFilter
+		op     BulkOperation
+		field  string
+	}{
+		{"empty filter", repository.SongFilter{}, BulkOperation{Type: BulkAddTag, Tag: "live"}, ""},
+		{"unknown type", filter, BulkOperation{Type: "rename"}, ""},
+		{"unknown field", filter, BulkOperation{Type: BulkSet, Field: "text", Value: "x"}, ""},
+		{"bad date", filter, BulkOperation{Type: BulkSet, Field: "release_date", Value: "yesterday"}, "operation.value"},
+		{"empty language", filter, BulkOperation{Type: BulkSet, Field: "language"}, "operation.value"},
+		{"blank tag", filter, BulkOperation{Type: BulkAddTag, Tag: "  "}, "operation.tag"},
+		{"missing pattern", filter, BulkOperation{Type: BulkReplaceText}, "operation.pattern"},
+		{"bad pattern", filter, BulkOperation{Type: BulkReplaceText, Pattern: "("}, "operation.pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.BulkUpdate(context.Background(), tt.filter, tt.op, false)
+			if !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("BulkUpdate error = %v, want a validation error", err)
+			}
+			if tt.field == "" {
+				return
+			}
+			var invalid *domain.ValidationError
+			if !errors.As(err, &invalid) || len(invalid.Fields) == 0 || invalid.Fields[0].Field != tt.field {
+				t.Errorf("BulkUpdate error = %v, want it on %s", err, tt.field)
+			}
+		})
+	}
+}
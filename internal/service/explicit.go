@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+
+	"log/slog"
+)
+
+// classifyExplicit records where a song's lyrics hit the explicit word
+// lists and flags it accordingly, unless previous carries a manual flag,
+// which is kept.
+func (s *songService) classifyExplicit(song *domain.Song, previous *domain.Song) {
+	song.ExplicitHits = s.explicit.Detect(lyrics.Parse(song.Text), song.Language)
+
+	if previous != nil && previous.ExplicitSource == domain.SourceManual {
+		song.Explicit = previous.Explicit
+		song.ExplicitSource = domain.SourceManual
+		return
+	}
+
+	song.Explicit = len(song.ExplicitHits) > 0
+	song.ExplicitSource = domain.SourceDetected
+}
+
+// SetSongExplicit flags a song as explicit or clean by hand. A nil flag
+// drops the override and goes back to the detected value.
+func (s *songService) SetSongExplicit(ctx context.Context, songID int, explicit *bool) (*domain.Song, error) {
+	s.logger.DebugLogger.Debug("Entering SetSongExplicit service", slog.Int("songID", songID))
+
+	song, err := s.repo.ModifySong(ctx, songID, func(song *domain.Song) error {
+		s.classifyExplicit(song, nil)
+		if explicit != nil {
+			song.Explicit = *explicit
+			song.ExplicitSource = domain.SourceManual
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error setting song explicit flag", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.InfoLogger.Info("Successfully set song explicit flag", slog.Int("songID", songID), slog.Bool("explicit", song.Explicit), slog.String("source", song.ExplicitSource))
+	return song, nil
+}
+
+// DetectExplicit re-runs explicit detection over every song, keeping
+// manual flags, and returns the number of songs whose flag changed. Songs
+// whose detection result is unchanged are not written.
+func (s *songService) DetectExplicit(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetSongIDs(ctx)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song IDs", slog.Any("error", err))
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		flagChanged := false
+		_, err := s.repo.ModifySong(ctx, id, func(song *domain.Song) error {
+			before := song.Explicit
+			s.classifyExplicit(song, song)
+			flagChanged = song.Explicit != before
+			return nil
+		})
+		if err != nil {
+			s.logger.ErrorLogger.Error("Error setting song explicit flag", slog.Int("songID", id), slog.Any("error", err))
+			return changed, err
+		}
+		if flagChanged {
+			changed++
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully re-detected explicit songs", slog.Int("songs", len(ids)), slog.Int("changed", changed))
+	return changed, nil
+}
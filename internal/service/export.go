@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/pkg/lrc"
+	"music-service/pkg/lyrics"
+	"time"
+
+	"log/slog"
+)
+
+// lastCueDuration is how long the final synced line stays on screen, as
+// nothing follows it to mark its end.
+const lastCueDuration = 4 * time.Second
+
+// exportBatchSize is the page size used to walk an artist's catalog.
+const exportBatchSize = 100
+
+// ExportSongLyrics assembles a song's lyrics for export, with subtitle cues
+// taken from its synced lyrics when it has them.
+func (s *songService) ExportSongLyrics(ctx context.Context, songID int) (*lyrics.Document, error) {
+	s.logger.DebugLogger.Debug("Entering ExportSongLyrics service", slog.Int("songID", songID))
+
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song for export", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	return s.exportDocument(ctx, *song)
+}
+
+// ExportArtistLyrics assembles the lyrics of every song by an artist, in
+// song ID order.
+func (s *songService) ExportArtistLyrics(ctx context.Context, artist string) ([]lyrics.Document, error) {
+	s.logger.DebugLogger.Debug("Entering ExportArtistLyrics service", slog.String("artist", artist))
+
+	var docs []lyrics.Document
+	page := repository.Page{Limit: exportBatchSize}
+	for {
+		songs, err := s.repo.GetSongs(ctx, repository.SongFilter{Artist: artist}, page)
+		if err != nil {
+			s.logger.ErrorLogger.Error("Error fetching songs for export", slog.String("artist", artist), slog.Any("error", err))
+			return nil, err
+		}
+
+		for _, song := range songs {
+			doc, err := s.exportDocument(ctx, song)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, *doc)
+		}
+
+		if len(songs) < exportBatchSize {
+			break
+		}
+		page.AfterID = songs[len(songs)-1].ID
+	}
+
+	s.logger.InfoLogger.Info("Assembled artist lyrics export", slog.String("artist", artist), slog.Int("songs", len(docs)))
+	return docs, nil
+}
+
+func (s *songService) exportDocument(ctx context.Context, song domain.Song) (*lyrics.Document, error) {
+	doc := &lyrics.Document{
+		Artist:  song.Group,
+		Title:   song.Song,
+		Stanzas: lyrics.Parse(song.Text),
+	}
+
+	synced, err := s.repo.GetSyncedLyrics(ctx, song.ID)
+	switch {
+	case errors.Is(err, repository.ErrNoSyncedLyrics):
+	case err != nil:
+		s.logger.ErrorLogger.Error("Error fetching synced lyrics for export", slog.Int("songID", song.ID), slog.Any("error", err))
+		return nil, err
+	default:
+		doc.Cues = syncedCues(synced)
+	}
+
+	return doc, nil
+}
+
+// syncedCues turns synced lines into subtitle cues, each lasting until the
+// next line starts.
+func syncedCues(lines []lrc.Line) []lyrics.Cue {
+	cues := make([]lyrics.Cue, 0, len(lines))
+	for i, line := range lines {
+		end := line.Time + lastCueDuration
+		if i+1 < len(lines) {
+			end = lines[i+1].Time
+		}
+		if line.Text == "" {
+			continue
+		}
+		cues = append(cues, lyrics.Cue{Start: line.Time, End: end, Text: line.Text})
+	}
+	return cues
+}
+
+// ExportSongs calls fn with every song matching filter, in ID order, from a
+// single consistent snapshot of the library. An error from fn stops the
+// export and is returned as-is.
+func (s *songService) ExportSongs(ctx context.Context, filter repository.SongFilter, fn func(song domain.Song) error) error {
+	s.logger.DebugLogger.Debug("Entering ExportSongs service", slog.Any("filter", filter))
+
+	if err := s.repo.ExportSongs(ctx, filter, fn); err != nil {
+		s.logger.ErrorLogger.Error("Error exporting songs", slog.Any("error", err))
+		return err
+	}
+	return nil
+}
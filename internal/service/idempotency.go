@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/pkg/logger"
+	"time"
+
+	"log/slog"
+)
+
+const (
+	// idempotencyStaleAfter is how long a request may go without refreshing
+	// its key before a retry takes the key over, e.g. after a crash.
+	idempotencyStaleAfter = 5 * time.Minute
+	// idempotencyRefreshInterval is how often a running request refreshes
+	// its key; it is well below idempotencyStaleAfter.
+	idempotencyRefreshInterval = time.Minute
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key arrives with a request
+	// other than the one it was first used for.
+	ErrIdempotencyKeyReused = domain.Conflict(errors.New("idempotency key was already used for a different request"))
+	// ErrIdempotencyKeyInUse is returned for a retry that arrives while
+	// the first request with its key is still running.
+	ErrIdempotencyKeyInUse = domain.Conflict(errors.New("a request with this idempotency key is still in progress"))
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, key, fingerprint string) (*repository.IdempotentResponse, error)
+	Hold(ctx context.Context, key string)
+	Complete(ctx context.Context, key string, response repository.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+	RunPurger(ctx context.Context, interval time.Duration)
+}
+
+type idempotencyService struct {
+	repo   repository.IdempotencyRepository
+	ttl    time.Duration
+	logger *logger.Loggers
+}
+
+// NewIdempotencyService keeps the responses to idempotent requests for ttl.
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration, logger *logger.Loggers) IdempotencyService {
+	return &idempotencyService{
+		repo:   repo,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Fingerprint identifies a request by its method, target, the header lines
+// that change its meaning, such as Content-Type and If-Match, and its body,
+// so a key reused for a different request is noticed.
+func Fingerprint(method, target string, headers []string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + target + "\n"))
+	for _, header := range headers {
+		h.Write([]byte(header + "\n"))
+	}
+	h.Write([]byte("\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key for the request with the given fingerprint. It returns
+// nil if the request should run, to be followed by Complete or Release,
+// or the stored response if the request already ran.
+func (s *idempotencyService) Begin(ctx context.Context, key, fingerprint string) (*repository.IdempotentResponse, error) {
+	s.logger.DebugLogger.Debug("Entering Begin idempotency service", slog.String("key", key))
+
+	now := time.Now()
+	record, err := s.repo.ClaimIdempotencyKey(ctx, key, fingerprint, now.Add(s.ttl), now.Add(-idempotencyStaleAfter))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case record == nil:
+		return nil, nil
+	case record.Fingerprint != fingerprint:
+		s.logger.ErrorLogger.Error("Idempotency key reused for a different request", slog.String("key", key))
+		return nil, ErrIdempotencyKeyReused
+	case record.Response == nil:
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	s.logger.InfoLogger.Info("Replaying idempotent response", slog.String("key", key), slog.Int("status", record.Response.Status))
+	return record.Response, nil
+}
+
+// Hold keeps key claimed by refreshing it until ctx is done, so a request
+// that runs longer than idempotencyStaleAfter is not run a second time by
+// a retry.
+func (s *idempotencyService) Hold(ctx context.Context, key string) {
+	ticker := time.NewTicker(idempotencyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.repo.RefreshIdempotencyKey(ctx, key); err != nil && ctx.Err() == nil {
+				s.logger.ErrorLogger.Error("Error refreshing idempotency key", slog.String("key", key), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Complete stores the response to the request holding key.
+func (s *idempotencyService) Complete(ctx context.Context, key string, response repository.IdempotentResponse) error {
+	return s.repo.CompleteIdempotencyKey(ctx, key, response)
+}
+
+// Release gives up key after its request failed, so a retry runs it again.
+func (s *idempotencyService) Release(ctx context.Context, key string) error {
+	return s.repo.DeleteIdempotencyKey(ctx, key)
+}
+
+// RunPurger deletes expired keys every interval until ctx is done.
+func (s *idempotencyService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpiredIdempotencyKeys(ctx)
+			if err != nil {
+				s.logger.ErrorLogger.Error("Error purging idempotency keys", slog.Any("error", err))
+				continue
+			}
+			s.logger.DebugLogger.Debug("Purged expired idempotency keys", slog.Int("deleted", deleted))
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+// importBatchSize is the number of songs written per transaction.
+const importBatchSize = 100
+
+// maxImportLineSize bounds a single NDJSON line.
+const maxImportLineSize = 1 << 20
+
+// ImportOptions select how ImportSongs treats songs that already exist; see
+// repository.ImportOptions.
+type ImportOptions struct {
+	Upsert bool
+	DryRun bool
+}
+
+// ImportRowResult reports the outcome of one record of an import. Line is
+// the line of the input the record starts on. Song is the stored song, or
+// the one that would be stored in a dry run, and Err is set for
+// repository.ImportFailed.
+type ImportRowResult struct {
+	Line   int
+	Status repository.ImportStatus
+	Song   domain.Song
+	Err    error
+}
+
+// ImportSummary counts the records of an import by outcome.
+type ImportSummary map[repository.ImportStatus]int
+
+// SongSource yields the songs of an import one record at a time. Next
+// returns io.EOF after the last record. A record that cannot be read as a
+// song is reported with a validation error and the source moves on; any
+// other error ends the import.
+type SongSource interface {
+	Next() (line int, song domain.Song, err error)
+}
+
+// ImportSongs validates and stores the songs of source in batches, calling
+// report with the results of each batch as soon as it is written. Batches
+// already written stay written if a later one fails.
+func (s *songService) ImportSongs(ctx context.Context, source SongSource, opts ImportOptions, report func([]ImportRowResult) error) (ImportSummary, error) {
+	s.logger.DebugLogger.Debug("Entering ImportSongs service", slog.Bool("upsert", opts.Upsert), slog.Bool("dryRun", opts.DryRun))
+
+	importer, err := s.repo.BeginImport(ctx, repository.ImportOptions{
+		Upsert: opts.Upsert,
+		DryRun: opts.DryRun,
+		Prepare: func(song, previous *domain.Song) error {
+			song.RawText = song.Text
+			song.Text = s.normalizer.Normalize(song.Text)
+			if err := resolveLanguage(song, previous); err != nil {
+				return err
+			}
+			s.classifyExplicit(song, previous)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer importer.Close()
+
+	summary := ImportSummary{}
+	var rows []ImportRowResult
+	flush := func() error {
+		var songs []domain.Song
+		for _, row := range rows {
+			if row.Err == nil {
+				songs = append(songs, row.Song)
+			}
+		}
+		if len(songs) > 0 {
+			results, err := importer.ImportBatch(ctx, songs)
+			if err != nil {
+				return err
+			}
+			for i := range rows {
+				if rows[i].Err != nil {
+					continue
+				}
+				rows[i].Status, rows[i].Song, rows[i].Err = results[0].Status, results[0].Song, results[0].Err
+				results = results[1:]
+			}
+		}
+
+		for _, row := range rows {
+			summary[row.Status]++
+			if !opts.DryRun && (row.Status == repository.ImportCreated || row.Status == repository.ImportUpdated) {
+				s.stats.invalidate(row.Song.ID)
+			}
+		}
+		err := report(rows)
+		rows = rows[:0]
+		return err
+	}
+
+	for {
+		line, song, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+		case err != nil:
+			s.logger.ErrorLogger.Error("Error reading import", slog.Int("line", line), slog.Any("error", err))
+			return summary, err
+		default:
+			err = validateSong(&song)
+		}
+
+		row := ImportRowResult{Line: line, Song: song, Err: err}
+		if err != nil {
+			row.Status = repository.ImportFailed
+		}
+		rows = append(rows, row)
+
+		if len(rows) >= importBatchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return summary, err
+	}
+
+	s.logger.InfoLogger.Info("Successfully imported songs", slog.Any("summary", summary), slog.Bool("dryRun", opts.DryRun))
+	return summary, nil
+}
+
+// ndjsonSource reads one JSON song per line, skipping blank lines.
+type ndjsonSource struct {
+	scanner *bufio.Scanner
+	line    int
+	failed  bool
+}
+
+// NewNDJSONSource reads songs from newline-delimited JSON, each object in
+// the shape accepted by POST /songs.
+func NewNDJSONSource(r io.Reader) SongSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+	return &ndjsonSource{scanner: scanner}
+}
+
+func (s *ndjsonSource) Next() (int, domain.Song, error) {
+	for s.scanner.Scan() {
+		s.line++
+		record := bytes.TrimSpace(s.scanner.Bytes())
+		if len(record) == 0 {
+			continue
+		}
+
+		song, err := DecodeSong(bytes.NewReader(record))
+		if errors.Is(err, ErrMalformedPayload) {
+			err = domain.Validation(err)
+		}
+		return s.line, song, err
+	}
+	if err := s.scanner.Err(); err != nil && !s.failed {
+		// The scanner cannot resume after an error, so the record is
+		// reported and the import ends with it.
+		s.failed = true
+		if errors.Is(err, bufio.ErrTooLong) {
+			return s.line + 1, domain.Song{}, domain.Validation(fmt.Errorf("line is longer than %d bytes", maxImportLineSize))
+		}
+		return s.line + 1, domain.Song{}, err
+	}
+	return s.line, domain.Song{}, io.EOF
+}
+
+// csvFields are the song fields a CSV column can be mapped to.
+var csvFields = []string{"group", "song", "release_date", "text", "link", "tags", "language"}
+
+// csvIgnore maps a CSV column to no field.
+const csvIgnore = "-"
+
+// ErrInvalidCSVHeader is returned by NewCSVSource for a header that does not
+// map onto the song fields.
+var ErrInvalidCSVHeader = domain.Validation(errors.New("invalid CSV header"))
+
+// csvSource reads one song per CSV record.
+type csvSource struct {
+	reader *csv.Reader
+	fields []string
+}
+
+// NewCSVSource reads songs from CSV with a header row. Columns are named
+// after the song fields unless mapping renames them: it maps header names
+// to fields, and to "-" for columns to ignore. Tags are separated by
+// semicolons and release dates are YYYY-MM-DD or RFC 3339 timestamps.
+func NewCSVSource(r io.Reader, mapping map[string]string) (SongSource, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the input is empty", ErrInvalidCSVHeader)
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSVHeader, err)
+		}
+		return nil, err
+	}
+
+	source := &csvSource{reader: reader, fields: make([]string, len(header))}
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		field, ok := mapping[name]
+		if !ok {
+			field = name
+		}
+		if field != csvIgnore {
+			if !isCSVField(field) {
+				return nil, fmt.Errorf("%w: column %q is not a song field; map it to one or to %q", ErrInvalidCSVHeader, name, csvIgnore)
+			}
+			if seen[field] {
+				return nil, fmt.Errorf("%w: more than one column maps to %s", ErrInvalidCSVHeader, field)
+			}
+			seen[field] = true
+		}
+		source.fields[i] = field
+	}
+	for from, field := range mapping {
+		if field != csvIgnore && !isCSVField(field) {
+			return nil, fmt.Errorf("%w: cannot map %q to unknown field %q", ErrInvalidCSVHeader, from, field)
+		}
+	}
+	if !seen["group"] || !seen["song"] {
+		return nil, fmt.Errorf("%w: columns for group and song are required", ErrInvalidCSVHeader)
+	}
+	return source, nil
+}
+
+func isCSVField(field string) bool {
+	return slices.Contains(csvFields, field)
+}
+
+func (s *csvSource) Next() (int, domain.Song, error) {
+	record, err := s.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, domain.Song{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, domain.Song{}, domain.Validation(err)
+	}
+	if err != nil {
+		return 0, domain.Song{}, err
+	}
+	line, _ := s.reader.FieldPos(0)
+
+	var song domain.Song
+	for i, value := range record {
+		switch s.fields[i] {
+		case "group":
+			song.Group = value
+		case "song":
+			song.Song = value
+		case "release_date":
+			if value == "" {
+				continue
+			}
+			date, err := parseDate(value)
+			if err != nil {
+				return line, song, &domain.ValidationError{Fields: []domain.FieldError{{
+					Field:   "release_date",
+					Code:    "invalid_format",
+					Message: "must be a date as YYYY-MM-DD or an RFC 3339 timestamp",
+				}}}
+			}
+			song.ReleaseDate = date
+		case "text":
+			song.Text = value
+		case "link":
+			song.Link = value
+		case "tags":
+			for _, tag := range strings.Split(value, ";") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					song.Tags = append(song.Tags, tag)
+				}
+			}
+		case "language":
+			song.Language = value
+		}
+	}
+	return line, song, nil
+}
+
+// parseDate reads a date given as YYYY-MM-DD or as an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
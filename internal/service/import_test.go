@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func importAll(t *testing.T, svc *songService, input string, opts ImportOptions) (ImportSummary, []ImportRowResult) {
+	t.Helper()
+
+	var rows []ImportRowResult
+	summary, err := svc.ImportSongs(context.Background(), NewNDJSONSource(strings.NewReader(input)), opts, func(batch []ImportRowResult) error {
+		rows = append(rows, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ImportSongs: %v", err)
+	}
+	return summary, rows
+}
+
+func TestImportSongs(t *testing.T) {
+	repo := newFakeSongRepo(domain.Song{ID: 1, Group: "Muse", Song: "Uprising", Text: "They will not force us"})
+	svc := newTestSongService(t, repo)
+
+	input := `{"group": "Muse", "song": "Madness", "release_date": "2012-08-20T00:00:00Z", "text": "“I”  \nhave finally seen the light"}
+
+{"group": "muse", "song": "uprising", "release_date": "2009-09-07T00:00:00Z", "text": "Rise up"}
+{"group": "Muse"
+{"group": "Muse", "release_date": "2012-08-20T00:00:00Z"}
+`
+	summary, rows := importAll(t, svc, input, ImportOptions{})
+
+	want := ImportSummary{repository.ImportCreated: 1, repository.ImportDuplicate: 1, repository.ImportFailed: 2}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %v, want %v", summary, want)
+	}
+
+	var lines []int
+	for _, row := range rows {
+		lines = append(lines, row.Line)
+		if row.Status == repository.ImportFailed && !errors.Is(row.Err, domain.ErrValidation) {
+			t.Errorf("line %d error = %v, want a validation error", row.Line, row.Err)
+		}
+	}
+	if want := []int{1, 3, 4, 5}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %v, want %v", lines, want)
+	}
+
+	created := repo.songs[2]
+	if created.RawText != "“I”  \nhave finally seen the light" || created.Text != "\"I\"\nhave finally seen the light" {
+		t.Errorf("created song text = %q / %q, want the submitted and the normalized lyrics", created.RawText, created.Text)
+	}
+	if repo.songs[1].Text != "They will not force us" {
+		t.Errorf("duplicate replaced the stored lyrics with %q", repo.songs[1].Text)
+	}
+}
+
+func TestImportSongsUpsert(t *testing.T) {
+	repo := newFakeSongRepo(domain.Song{ID: 1, Group: "Muse", Song: "Uprising", Text: "They will not force us"})
+	svc := newTestSongService(t, repo)
+	ctx := context.Background()
+
+	if vocabulary, err := svc.GetArtistVocabulary(ctx, "Muse"); err != nil || vocabulary.Words != 5 {
+		t.Fatalf("GetArtistVocabulary = %+v, %v, want 5 words", vocabulary, err)
+	}
+
+	input := `{"group": "Muse", "song": "Uprising", "release_date": "2009-09-07T00:00:00Z", "text": "Rise up"}`
+	summary, _ := importAll(t, svc, input, ImportOptions{Upsert: true, DryRun: true})
+	if summary[repository.ImportUpdated] != 1 || repo.songs[1].Text != "They will not force us" {
+		t.Fatalf("dry run summary = %v with lyrics %q, want an update that is not stored", summary, repo.songs[1].Text)
+	}
+
+	summary, _ = importAll(t, svc, input, ImportOptions{Upsert: true})
+	if summary[repository.ImportUpdated] != 1 || repo.songs[1].Text != "Rise up" {
+		t.Fatalf("summary = %v with lyrics %q, want the song updated", summary, repo.songs[1].Text)
+	}
+
+	// The update invalidates the cached vocabulary of the artist.
+	if vocabulary, err := svc.GetArtistVocabulary(ctx, "Muse"); err != nil || vocabulary.Words != 2 {
+		t.Errorf("GetArtistVocabulary after the import = %+v, %v, want 2 words", vocabulary, err)
+	}
+}
+
+func TestNewCSVSourceHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		mapping map[string]string
+		ok      bool
+	}{
+		{"song fields", "group,song,text", nil, true},
+		{"mapped", "Artist,Title,Notes", map[string]string{"Artist": "group", "Title": "song", "Notes": csvIgnore}, true},
+		{"unknown column", "group,song,notes", nil, false},
+		{"mapped twice", "group,Artist,song", map[string]string{"Artist": "group"}, false},
+		{"missing song", "group,text", nil, false},
+		{"unknown target", "group,song", map[string]string{"x": "lyrics"}, false},
+		{"empty", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCSVSource(strings.NewReader(tt.header), tt.mapping)
+			if tt.ok && err != nil {
+				t.Errorf("NewCSVSource: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidCSVHeader) {
+				t.Errorf("NewCSVSource error = %v, want ErrInvalidCSVHeader", err)
+			}
+		})
+	}
+}
+
+func TestCSVSourceNext(t *testing.T) {
+	source, err := NewCSVSource(strings.NewReader("group,song,release_date,tags\nMuse,Madness,2012-08-20, rock ;; live\nMuse,Uprising,someday,\n"), nil)
+	if err != nil {
+		t.Fatalf("NewCSVSource: %v", err)
+	}
+
+	line, song, err := source.Next()
+	if err != nil || line != 2 || song.Song != "Madness" || song.ReleaseDate.Year() != 2012 || !reflect.DeepEqual(song.Tags, []string{"rock", "live"}) {
+		t.Errorf("first record = line %d %+v, %v", line, song, err)
+	}
+
+	line, _, err = source.Next()
+	var invalid *domain.ValidationError
+	if line != 3 || !errors.As(err, &invalid) || invalid.Fields[0].Field != "release_date" {
+		t.Errorf("second record = line %d, %v, want a release_date error on line 3", line, err)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"music-service/internal/domain"
+	"music-service/pkg/langdetect"
+	"regexp"
+	"strings"
+
+	"log/slog"
+)
+
+var ErrInvalidLanguage = domain.Validation(errors.New("language must be a two-letter ISO 639-1 code"))
+
+var languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+
+// resolveLanguage fills in a song's language before it is written. A
+// language given by the client is a manual override unless it merely echoes
+// the previously detected one; without one the stored override is kept, or
+// the language is detected from the lyrics. previous is nil for new songs.
+func resolveLanguage(song *domain.Song, previous *domain.Song) error {
+	song.Language = strings.ToLower(strings.TrimSpace(song.Language))
+	song.LanguageConfidence = 0
+	song.LanguageSource = ""
+
+	manual := previous != nil && previous.LanguageSource == domain.SourceManual
+	switch {
+	case song.Language == "" && manual:
+		song.Language = previous.Language
+		song.LanguageSource = domain.SourceManual
+		return nil
+	case song.Language != "" && (previous == nil || manual || song.Language != previous.Language):
+		if !languageCode.MatchString(song.Language) {
+			return ErrInvalidLanguage
+		}
+		song.LanguageSource = domain.SourceManual
+		return nil
+	}
+
+	detectLanguage(song)
+	return nil
+}
+
+// detectLanguage sets a song's language from its lyrics. The confidence is
+// rounded to what the REAL column keeps, so re-detecting an unchanged song
+// compares equal and writes nothing.
+func detectLanguage(song *domain.Song) {
+	result := langdetect.Detect(song.Text)
+	song.Language = result.Language
+	song.LanguageConfidence = math.Round(result.Confidence*1000) / 1000
+	song.LanguageSource = ""
+	if result.Language != "" {
+		song.LanguageSource = domain.SourceDetected
+	}
+}
+
+// SetSongLanguage overrides a song's language by hand. An empty language
+// drops the override and detects the language from the lyrics again.
+func (s *songService) SetSongLanguage(ctx context.Context, songID int, language string) (*domain.Song, error) {
+	s.logger.DebugLogger.Debug("Entering SetSongLanguage service", slog.Int("songID", songID), slog.String("language", language))
+
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "" && !languageCode.MatchString(language) {
+		return nil, ErrInvalidLanguage
+	}
+
+	song, err := s.repo.ModifySong(ctx, songID, func(song *domain.Song) error {
+		if language == "" {
+			detectLanguage(song)
+		} else {
+			song.Language = language
+			song.LanguageConfidence = 0
+			song.LanguageSource = domain.SourceManual
+		}
+		// The explicit word lists are per language.
+		s.classifyExplicit(song, song)
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error setting song language", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+	s.stats.invalidate(songID)
+
+	s.logger.InfoLogger.Info("Successfully set song language", slog.Int("songID", songID), slog.String("language", song.Language), slog.String("source", song.LanguageSource))
+	return song, nil
+}
+
+// DetectLanguages re-detects the language of every song without a manual
+// override and returns the number of songs whose language changed. Songs
+// whose detection result is unchanged are not written.
+func (s *songService) DetectLanguages(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetSongIDs(ctx)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song IDs", slog.Any("error", err))
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		languageChanged := false
+		_, err := s.repo.ModifySong(ctx, id, func(song *domain.Song) error {
+			if song.LanguageSource == domain.SourceManual {
+				return nil
+			}
+			before := song.Language
+			detectLanguage(song)
+			languageChanged = song.Language != before
+			return nil
+		})
+		if err != nil {
+			s.logger.ErrorLogger.Error("Error setting song language", slog.Int("songID", id), slog.Any("error", err))
+			return changed, err
+		}
+		if languageChanged {
+			changed++
+			s.stats.invalidate(id)
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully re-detected languages", slog.Int("songs", len(ids)), slog.Int("changed", changed))
+	return changed, nil
+}
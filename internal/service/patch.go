@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/pkg/patch"
+
+	"log/slog"
+)
+
+var ErrInvalidSong = domain.Validation(errors.New("invalid song"))
+
+// PatchSong applies a JSON Merge Patch or JSON Patch to a song, as it is
+// returned by GET /songs/{id}, and stores the result. Only group, song,
+// release_date, text, link, tags and language may change; the other fields
+// are derived or have their own endpoints. As with UpdateSong, an empty
+// language keeps a manual override. A non-zero version must match the
+// stored one.
+func (s *songService) PatchSong(ctx context.Context, songID, version int, format patch.Format, body []byte) (*domain.Song, error) {
+	s.logger.DebugLogger.Debug("Entering PatchSong service", slog.Int("songID", songID), slog.String("format", string(format)))
+
+	updated, err := s.repo.ModifySong(ctx, songID, func(song *domain.Song) error {
+		if err := checkVersion(song, version); err != nil {
+			return err
+		}
+		previous := *song
+
+		doc, err := json.Marshal(previous)
+		if err != nil {
+			return err
+		}
+		patched, err := patch.Apply(format, doc, body)
+		if err != nil {
+			return patchError(err)
+		}
+
+		result, err := DecodeSong(bytes.NewReader(patched))
+		if err != nil {
+			if errors.Is(err, ErrMalformedPayload) {
+				return fmt.Errorf("%w: %v", ErrInvalidSong, err)
+			}
+			return err
+		}
+		if field := readOnlyChange(previous, result); field != "" {
+			return &domain.ValidationError{Fields: []domain.FieldError{{Field: field, Code: "read_only", Message: "cannot be changed"}}}
+		}
+		if err := validateSong(&result); err != nil {
+			return err
+		}
+
+		result.RawText = previous.RawText
+		if result.Text != previous.Text {
+			result.RawText = result.Text
+			result.Text = s.normalizer.Normalize(result.Text)
+		}
+		if err := resolveLanguage(&result, &previous); err != nil {
+			return err
+		}
+		s.classifyExplicit(&result, &previous)
+
+		*song = result
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error patching song", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+	s.stats.invalidate(songID)
+
+	s.logger.InfoLogger.Info("Successfully patched song", slog.Int("songID", songID))
+	return updated, nil
+}
+
+// readOnlyChange names the first field a patch may not change that differs
+// between before and after, or returns "".
+func readOnlyChange(before, after domain.Song) string {
+	switch {
+	case after.ID != before.ID:
+		return "id"
+	case !after.CreatedAt.Equal(before.CreatedAt):
+		return "created_at"
+	case after.LanguageConfidence != before.LanguageConfidence:
+		return "language_confidence"
+	case after.LanguageSource != before.LanguageSource:
+		return "language_source"
+	case after.Explicit != before.Explicit:
+		return "explicit"
+	case after.ExplicitSource != before.ExplicitSource:
+		return "explicit_source"
+	}
+	return ""
+}
+
+// patchError gives the errors of patch.Apply their domain kind.
+func patchError(err error) error {
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		return domain.Conflict(err)
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrUnsupportedFormat):
+		return domain.Validation(err)
+	}
+	return err
+}
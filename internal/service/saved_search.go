@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+var ErrInvalidSavedSearch = domain.Validation(errors.New("invalid saved search"))
+
+// webhookBatchSize caps how many new songs are sent in one notification;
+// the rest go out on the next tick.
+const webhookBatchSize = 100
+
+type SavedSearchService interface {
+	CreateSavedSearch(ctx context.Context, search repository.SavedSearch) (*repository.SavedSearch, error)
+	GetSavedSearches(ctx context.Context) ([]repository.SavedSearch, error)
+	GetSavedSearchByID(ctx context.Context, id int) (*repository.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id int) error
+	GetSavedSearchResults(ctx context.Context, id int, page PageRequest) (*SongPage, error)
+	GetNewSavedSearchResults(ctx context.Context, id int, page PageRequest) (*NewSongsPage, error)
+	MarkSavedSearchChecked(ctx context.Context, id int, checkedAt time.Time) error
+	RunNotifier(ctx context.Context, interval time.Duration)
+}
+
+// NewSongsPage holds songs added since a saved search was last checked.
+// AsOf is the time the page was computed; passing it back when marking the
+// search as checked avoids skipping songs added in the meantime.
+type NewSongsPage struct {
+	*SongPage
+	Since time.Time
+	AsOf  time.Time
+}
+
+type WebhookPayload struct {
+	SavedSearchID int           `json:"saved_search_id"`
+	Name          string        `json:"name"`
+	Songs         []domain.Song `json:"songs"`
+}
+
+type savedSearchService struct {
+	repo   repository.SavedSearchRepository
+	songs  SongService
+	client *http.Client
+	logger *logger.Loggers
+}
+
+func NewSavedSearchService(repo repository.SavedSearchRepository, songs SongService, client *http.Client, logger *logger.Loggers) SavedSearchService {
+	return &savedSearchService{
+		repo:   repo,
+		songs:  songs,
+		client: client,
+		logger: logger,
+	}
+}
+
+func (s *savedSearchService) CreateSavedSearch(ctx context.Context, search repository.SavedSearch) (*repository.SavedSearch, error) {
+	s.logger.DebugLogger.Debug("Entering CreateSavedSearch service", slog.Any("search", search))
+
+	search.Name = strings.TrimSpace(search.Name)
+	if err := validateSavedSearch(ctx, search); err != nil {
+		s.logger.ErrorLogger.Error("Invalid saved search", slog.Any("error", err))
+		return nil, err
+	}
+
+	created, err := s.repo.CreateSavedSearch(ctx, search)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error creating saved search", slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.InfoLogger.Info("Successfully created saved search", slog.Int("id", created.ID))
+	return created, nil
+}
+
+func validateSavedSearch(ctx context.Context, search repository.SavedSearch) error {
+	if search.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedSearch)
+	}
+
+	if search.Filter.Decade%10 != 0 {
+		return fmt.Errorf("%w: invalid decade %d", ErrInvalidSavedSearch, search.Filter.Decade)
+	}
+
+	if search.Query != "" {
+		if err := repository.ValidateSongQuery(search.Query); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+		}
+	}
+
+	if search.WebhookURL != "" {
+		if err := validateWebhookURL(ctx, search.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *savedSearchService) GetSavedSearches(ctx context.Context) ([]repository.SavedSearch, error) {
+	return s.repo.GetSavedSearches(ctx)
+}
+
+func (s *savedSearchService) GetSavedSearchByID(ctx context.Context, id int) (*repository.SavedSearch, error) {
+	return s.repo.GetSavedSearchByID(ctx, id)
+}
+
+func (s *savedSearchService) DeleteSavedSearch(ctx context.Context, id int) error {
+	s.logger.DebugLogger.Debug("Entering DeleteSavedSearch service", slog.Int("id", id))
+
+	if err := s.repo.DeleteSavedSearch(ctx, id); err != nil {
+		s.logger.ErrorLogger.Error("Error deleting saved search", slog.Int("id", id), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.InfoLogger.Info("Successfully deleted saved search", slog.Int("id", id))
+	return nil
+}
+
+func (s *savedSearchService) GetSavedSearchResults(ctx context.Context, id int, page PageRequest) (*SongPage, error) {
+	search, err := s.repo.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.songs.GetSongs(ctx, search.SongFilter(), page)
+}
+
+func (s *savedSearchService) GetNewSavedSearchResults(ctx context.Context, id int, page PageRequest) (*NewSongsPage, error) {
+	asOf := time.Now()
+
+	search, err := s.repo.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := search.SongFilter()
+	filter.CreatedAfter = &search.LastCheckedAt
+
+	songs, err := s.songs.GetSongs(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NewSongsPage{SongPage: songs, Since: search.LastCheckedAt, AsOf: asOf}, nil
+}
+
+func (s *savedSearchService) MarkSavedSearchChecked(ctx context.Context, id int, checkedAt time.Time) error {
+	s.logger.DebugLogger.Debug("Entering MarkSavedSearchChecked service", slog.Int("id", id), slog.Time("checkedAt", checkedAt))
+
+	if err := s.repo.MarkSavedSearchChecked(ctx, id, checkedAt); err != nil {
+		s.logger.ErrorLogger.Error("Error marking saved search as checked", slog.Int("id", id), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// RunNotifier periodically posts songs that newly match a saved search to
+// its webhook, until ctx is cancelled.
+func (s *savedSearchService) RunNotifier(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.notifyAll(ctx)
+		}
+	}
+}
+
+func (s *savedSearchService) notifyAll(ctx context.Context) {
+	searches, err := s.repo.GetSavedSearchesWithWebhook(ctx)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching saved searches with webhooks", slog.Any("error", err))
+		return
+	}
+
+	for _, search := range searches {
+		if err := s.notify(ctx, search); err != nil {
+			s.logger.ErrorLogger.Error("Failed to notify saved search webhook", slog.Int("id", search.ID), slog.Any("error", err))
+		}
+	}
+}
+
+func (s *savedSearchService) notify(ctx context.Context, search repository.SavedSearch) error {
+	filter := search.SongFilter()
+	filter.AddedAfterID = search.LastNotifiedID
+
+	page, err := s.songs.GetSongs(ctx, filter, PageRequest{Limit: webhookBatchSize})
+	if err != nil {
+		return err
+	}
+	if len(page.Songs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{SavedSearchID: search.ID, Name: search.Name, Songs: page.Songs})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, search.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	// Pages are in id order, so the songs past the cap all have greater
+	// ids than the last one sent and go out on the next tick.
+	last := page.Songs[len(page.Songs)-1].ID
+
+	s.logger.InfoLogger.Info("Notified saved search webhook", slog.Int("id", search.ID), slog.Int("songs", len(page.Songs)))
+	return s.repo.MarkSavedSearchNotified(ctx, search.ID, last)
+}
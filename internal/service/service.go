@@ -2,99 +2,358 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
 	"music-service/internal/domain"
 	"music-service/internal/repository"
+	"music-service/pkg/cursor"
+	"music-service/pkg/explicit"
 	"music-service/pkg/logger"
+	"music-service/pkg/lrc"
+	"music-service/pkg/lyrics"
+	"music-service/pkg/patch"
+	"time"
 
 	"log/slog"
 )
 
 type SongService interface {
-	GetSongs(ctx context.Context, filter repository.SongFilter, limit, offset int) ([]domain.Song, error)
-	GetSongLyricsPaginated(ctx context.Context, songID int, limit, offset int) ([]string, error)
-	DeleteSong(ctx context.Context, songID int) error
-	UpdateSong(ctx context.Context, song domain.Song) error
-	AddSong(ctx context.Context, song domain.Song) error
+	GetSongs(ctx context.Context, filter repository.SongFilter, page PageRequest) (*SongPage, error)
+	GetSongLyricsPaginated(ctx context.Context, songID int, unit lyrics.Unit, limit, offset int, opts LyricsOptions) (*repository.LyricsPage, error)
+	GetLyricsStructure(ctx context.Context, songID int) (*lyrics.Structure, error)
+	GetVerse(ctx context.Context, songID, verse int) (*lyrics.Stanza, error)
+	EditLyrics(ctx context.Context, songID int, hash string, edit lyrics.Edit) ([]lyrics.Stanza, int, error)
+	SearchSongLyrics(ctx context.Context, songID int, term string, opts lyrics.FoldOptions) ([]lyrics.Match, error)
+	DeleteSong(ctx context.Context, songID, version int) error
+	UpdateSong(ctx context.Context, song domain.Song) (*domain.Song, error)
+	PatchSong(ctx context.Context, songID, version int, format patch.Format, body []byte) (*domain.Song, error)
+	AddSong(ctx context.Context, song domain.Song) (*domain.Song, error)
+	BulkUpdate(ctx context.Context, filter repository.SongFilter, op BulkOperation, dryRun bool) (*repository.BulkResult, error)
+	ImportSongs(ctx context.Context, source SongSource, opts ImportOptions, report func([]ImportRowResult) error) (ImportSummary, error)
 	GetSongByID(ctx context.Context, songID int) (*domain.Song, error)
+	ReindexVerses(ctx context.Context) (int, error)
+	Renormalize(ctx context.Context) (int, error)
+	DetectExplicit(ctx context.Context) (int, error)
+	SetSongExplicit(ctx context.Context, songID int, explicit *bool) (*domain.Song, error)
+	ImportSyncedLyrics(ctx context.Context, songID int, r io.Reader) (*lrc.Lyrics, error)
+	GetSyncedLyrics(ctx context.Context, songID int) (*lrc.Lyrics, error)
+	DeleteSyncedLyrics(ctx context.Context, songID int) error
+	GetSyncedLineAt(ctx context.Context, songID int, t time.Duration) (*SyncedPosition, error)
+	ExportSongLyrics(ctx context.Context, songID int) (*lyrics.Document, error)
+	ExportArtistLyrics(ctx context.Context, artist string) ([]lyrics.Document, error)
+	ExportSongs(ctx context.Context, filter repository.SongFilter, fn func(song domain.Song) error) error
+	GetLyricsStats(ctx context.Context, songID int) (*lyrics.Stats, error)
+	GetArtistVocabulary(ctx context.Context, artist string) (*ArtistVocabulary, error)
+	SetSongLanguage(ctx context.Context, songID int, language string) (*domain.Song, error)
+	DetectLanguages(ctx context.Context) (int, error)
+}
+
+var ErrInvalidCursor = domain.Validation(errors.New("invalid pagination cursor"))
+
+// PageRequest describes which page of songs a caller wants. A non-empty
+// Cursor takes precedence over Offset.
+type PageRequest struct {
+	Limit        int
+	Offset       int
+	Cursor       string
+	IncludeTotal bool
+	Facets       []repository.FacetRequest
+}
+
+type SongPage struct {
+	Songs      []domain.Song
+	NextCursor string
+	PrevCursor string
+	Total      *int
+	Facets     map[string][]repository.FacetBucket
 }
 
 type songService struct {
-	repo   repository.SongRepository
-	logger *logger.Loggers
+	repo       repository.SongRepository
+	cursors    *cursor.Codec
+	normalizer *lyrics.Normalizer
+	explicit   *explicit.Detector
+	stats      *statsCache
+	logger     *logger.Loggers
+
+	// maxBulkAffected caps the songs a bulk operation may touch; zero
+	// means no cap.
+	maxBulkAffected int
 }
 
-func NewSongService(repo repository.SongRepository, logger *logger.Loggers) SongService {
+func NewSongService(repo repository.SongRepository, cursors *cursor.Codec, normalizer *lyrics.Normalizer, detector *explicit.Detector, maxBulkAffected int, logger *logger.Loggers) SongService {
 	return &songService{
-		repo:   repo,
-		logger: logger,
+		repo:            repo,
+		cursors:         cursors,
+		normalizer:      normalizer,
+		explicit:        detector,
+		stats:           newStatsCache(),
+		logger:          logger,
+		maxBulkAffected: maxBulkAffected,
 	}
 }
 
-func (s *songService) GetSongs(ctx context.Context, filter repository.SongFilter, limit, offset int) ([]domain.Song, error) {
-	s.logger.DebugLogger.Debug("Entering GetSongs service", slog.Any("filter", filter), slog.Int("limit", limit), slog.Int("offset", offset))
+func (s *songService) GetSongs(ctx context.Context, filter repository.SongFilter, page PageRequest) (*SongPage, error) {
+	s.logger.DebugLogger.Debug("Entering GetSongs service", slog.Any("filter", filter), slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+
+	// One extra row tells us whether another page exists in the direction
+	// we are reading.
+	repoPage := repository.Page{Limit: page.Limit + 1, Offset: page.Offset}
 
-	songs, err := s.repo.GetSongs(ctx, filter, limit, offset)
+	var cur cursor.Cursor
+	if page.Cursor != "" {
+		var err error
+		cur, err = s.cursors.Decode(page.Cursor)
+		if err != nil {
+			s.logger.ErrorLogger.Error("Invalid pagination cursor", slog.Any("error", err))
+			return nil, ErrInvalidCursor
+		}
+
+		repoPage.Offset = 0
+		if cur.Backward {
+			repoPage.BeforeID = cur.ID
+		} else {
+			repoPage.AfterID = cur.ID
+		}
+	}
+
+	list, err := s.repo.ListSongs(ctx, repository.SongListQuery{
+		Filter: filter,
+		Page:   repoPage,
+		Total:  page.IncludeTotal,
+		Facets: page.Facets,
+	})
 	if err != nil {
 		s.logger.ErrorLogger.Error("Error fetching songs", slog.Any("error", err))
 		return nil, err
 	}
+	songs := list.Songs
+
+	hasMore := len(songs) > page.Limit
+	if hasMore {
+		if cur.Backward {
+			songs = songs[1:]
+		} else {
+			songs = songs[:page.Limit]
+		}
+	}
+
+	result := &SongPage{Songs: songs, Total: list.Total, Facets: list.Facets}
+	if len(songs) > 0 {
+		first, last := songs[0].ID, songs[len(songs)-1].ID
+
+		if cur.Backward || hasMore {
+			result.NextCursor = s.cursors.Encode(cursor.Cursor{ID: last})
+		}
+
+		if (cur.Backward && hasMore) || (!cur.Backward && (page.Cursor != "" || page.Offset > 0)) {
+			result.PrevCursor = s.cursors.Encode(cursor.Cursor{ID: first, Backward: true})
+		}
+	}
 
 	s.logger.InfoLogger.Info("Successfully fetched songs", slog.Int("count", len(songs)))
-	return songs, nil
+	return result, nil
+}
+
+// LyricsOptions controls how a page of lyrics is presented. Compact leaves
+// out the lines of stanzas that repeat an earlier one; their RepeatOf
+// points back at it. Mask hides words from the explicit word lists.
+type LyricsOptions struct {
+	Compact bool
+	Mask    bool
 }
 
-func (s *songService) GetSongLyricsPaginated(ctx context.Context, songID int, limit, offset int) ([]string, error) {
-	s.logger.DebugLogger.Debug("Entering GetSongLyricsPaginated service", slog.Int("songID", songID), slog.Int("limit", limit), slog.Int("offset", offset))
+func (s *songService) GetSongLyricsPaginated(ctx context.Context, songID int, unit lyrics.Unit, limit, offset int, opts LyricsOptions) (*repository.LyricsPage, error) {
+	s.logger.DebugLogger.Debug("Entering GetSongLyricsPaginated service", slog.Int("songID", songID), slog.String("unit", string(unit)), slog.Int("limit", limit), slog.Int("offset", offset))
 
-	lyrics, err := s.repo.GetSongLyricsPaginated(ctx, songID, limit, offset)
+	page, err := s.repo.GetSongLyricsPaginated(ctx, songID, unit, limit, offset)
 	if err != nil {
 		s.logger.ErrorLogger.Error("Error fetching lyrics", slog.Int("songID", songID), slog.Any("error", err))
 		return nil, err
 	}
 
-	s.logger.InfoLogger.Info("Successfully fetched lyrics", slog.Int("songID", songID), slog.Int("versesCount", len(lyrics)))
-	return lyrics, nil
+	if opts.Mask {
+		page.Stanzas = s.explicit.Mask(page.Stanzas, page.Language)
+	}
+
+	if opts.Compact {
+		for i := range page.Stanzas {
+			if page.Stanzas[i].RepeatOf != nil {
+				page.Stanzas[i].Lines = []string{}
+			}
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully fetched lyrics", slog.Int("songID", songID), slog.Int("stanzasCount", len(page.Stanzas)))
+	return page, nil
 }
 
-func (s *songService) DeleteSong(ctx context.Context, songID int) error {
+func (s *songService) SearchSongLyrics(ctx context.Context, songID int, term string, opts lyrics.FoldOptions) ([]lyrics.Match, error) {
+	s.logger.DebugLogger.Debug("Entering SearchSongLyrics service", slog.Int("songID", songID), slog.String("term", term))
+
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song for lyrics search", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	matches := lyrics.Search(lyrics.Parse(song.Text), term, opts)
+
+	s.logger.InfoLogger.Info("Searched song lyrics", slog.Int("songID", songID), slog.Int("matches", len(matches)))
+	return matches, nil
+}
+
+// DeleteSong deletes a song. A non-zero version must match the stored one.
+func (s *songService) DeleteSong(ctx context.Context, songID, version int) error {
 	s.logger.DebugLogger.Debug("Entering DeleteSong service", slog.Int("songID", songID))
 
-	err := s.repo.DeleteSong(ctx, songID)
+	err := s.repo.DeleteSong(ctx, songID, version)
 	if err != nil {
 		s.logger.ErrorLogger.Error("Error deleting song", slog.Int("songID", songID), slog.Any("error", err))
 		return err
 	}
+	s.stats.invalidate(songID)
 
 	s.logger.InfoLogger.Info("Successfully deleted song", slog.Int("songID", songID))
 	return nil
 }
 
-func (s *songService) UpdateSong(ctx context.Context, song domain.Song) error {
+// UpdateSong replaces a song's details and returns the stored song. A
+// non-zero song.Version is the version the caller expects to replace.
+func (s *songService) UpdateSong(ctx context.Context, song domain.Song) (*domain.Song, error) {
 	s.logger.DebugLogger.Debug("Entering UpdateSong service", slog.Any("song", song))
 
-	err := s.repo.UpdateSong(ctx, song)
+	if err := validateSong(&song); err != nil {
+		return nil, err
+	}
+	song.RawText = song.Text
+	song.Text = s.normalizer.Normalize(song.Text)
+
+	updated, err := s.repo.ModifySong(ctx, song.ID, func(current *domain.Song) error {
+		if err := checkVersion(current, song.Version); err != nil {
+			return err
+		}
+		previous := *current
+		*current = song
+		current.CreatedAt = previous.CreatedAt
+		if err := resolveLanguage(current, &previous); err != nil {
+			return err
+		}
+		s.classifyExplicit(current, &previous)
+		return nil
+	})
 	if err != nil {
 		s.logger.ErrorLogger.Error("Error updating song", slog.Int("songID", song.ID), slog.Any("error", err))
-		return err
+		return nil, err
 	}
+	s.stats.invalidate(song.ID)
 
 	s.logger.InfoLogger.Info("Successfully updated song", slog.Int("songID", song.ID))
+	return updated, nil
+}
+
+// checkVersion returns repository.ErrSongModified unless version is zero or
+// the song's current version.
+func checkVersion(song *domain.Song, version int) error {
+	if version != 0 && version != song.Version {
+		return repository.ErrSongModified
+	}
 	return nil
 }
 
-func (s *songService) AddSong(ctx context.Context, song domain.Song) error {
+func (s *songService) AddSong(ctx context.Context, song domain.Song) (*domain.Song, error) {
 	s.logger.DebugLogger.Debug("Entering AddSong service", slog.Any("song", song))
 
-	err := s.repo.AddSong(ctx, song)
+	if err := validateSong(&song); err != nil {
+		return nil, err
+	}
+	song.RawText = song.Text
+	song.Text = s.normalizer.Normalize(song.Text)
+
+	if err := resolveLanguage(&song, nil); err != nil {
+		return nil, err
+	}
+	s.classifyExplicit(&song, nil)
+
+	created, err := s.repo.AddSong(ctx, song)
 	if err != nil {
 		s.logger.ErrorLogger.Error("Failed to store the song in the database", slog.Any("error", err))
-		return err
+		return nil, err
 	}
+	s.stats.invalidate(created.ID)
 
-	s.logger.InfoLogger.Info("Successfully added song", slog.Any("song", song))
-	return nil
+	s.logger.InfoLogger.Info("Successfully added song", slog.Int("songID", created.ID))
+	return created, nil
 }
 
 func (s *songService) GetSongByID(ctx context.Context, songID int) (*domain.Song, error) {
 	return s.repo.GetSongByID(ctx, songID)
 }
+
+// Renormalize re-applies the normalization steps to the submitted text of
+// every song and returns the number of songs whose lyrics changed.
+func (s *songService) Renormalize(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetSongIDs(ctx)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song IDs", slog.Any("error", err))
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		textChanged := false
+		_, err := s.repo.ModifySong(ctx, id, func(song *domain.Song) error {
+			text := s.normalizer.Normalize(song.RawText)
+			if text == song.Text {
+				return nil
+			}
+			song.Text = text
+			s.classifyExplicit(song, song)
+			textChanged = true
+			return nil
+		})
+		if err != nil {
+			s.logger.ErrorLogger.Error("Error renormalizing song", slog.Int("songID", id), slog.Any("error", err))
+			return changed, err
+		}
+		if textChanged {
+			s.stats.invalidate(id)
+			changed++
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully renormalized lyrics", slog.Int("songs", len(ids)), slog.Int("changed", changed))
+	return changed, nil
+}
+
+// GetLyricsStructure detects the repeated sections of a song's lyrics.
+func (s *songService) GetLyricsStructure(ctx context.Context, songID int) (*lyrics.Structure, error) {
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song for structure", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	structure := lyrics.DetectStructure(lyrics.Parse(song.Text))
+	return &structure, nil
+}
+
+// ReindexVerses rebuilds the stored stanzas of every song from its lyrics
+// and returns the number of songs processed.
+func (s *songService) ReindexVerses(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetSongIDs(ctx)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song IDs", slog.Any("error", err))
+		return 0, err
+	}
+
+	for i, id := range ids {
+		if err := s.repo.RebuildVerses(ctx, id); err != nil {
+			s.logger.ErrorLogger.Error("Error rebuilding verses", slog.Int("songID", id), slog.Any("error", err))
+			return i, err
+		}
+	}
+
+	s.logger.InfoLogger.Info("Successfully reindexed verses", slog.Int("count", len(ids)))
+	return len(ids), nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/internal/repository"
+	"music-service/pkg/cursor"
+	"music-service/pkg/explicit"
+	"music-service/pkg/logger"
+	"music-service/pkg/lyrics"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeSongRepo keeps songs in memory. Methods a test does not need fall
+// through to the nil embedded interface and panic.
+type fakeSongRepo struct {
+	repository.SongRepository
+	songs map[int]domain.Song
+	reads int
+}
+
+func newFakeSongRepo(songs ...domain.Song) *fakeSongRepo {
+	repo := &fakeSongRepo{songs: map[int]domain.Song{}}
+	for _, song := range songs {
+		if song.Version == 0 {
+			song.Version = 1
+		}
+		repo.songs[song.ID] = song
+	}
+	return repo
+}
+
+func (r *fakeSongRepo) ids() []int {
+	ids := make([]int, 0, len(r.songs))
+	for id := range r.songs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (r *fakeSongRepo) GetSongByID(ctx context.Context, songID int) (*domain.Song, error) {
+	r.reads++
+	song, ok := r.songs[songID]
+	if !ok {
+		return nil, repository.ErrSongNotFound
+	}
+	return &song, nil
+}
+
+func (r *fakeSongRepo) ModifySong(ctx context.Context, songID int, fn func(song *domain.Song) error) (*domain.Song, error) {
+	song, ok := r.songs[songID]
+	if !ok {
+		return nil, repository.ErrSongNotFound
+	}
+	song.Tags = slices.Clone(song.Tags)
+	if err := fn(&song); err != nil {
+		return nil, err
+	}
+	song.Version++
+	r.songs[songID] = song
+	return &song, nil
+}
+
+// ListSongs ignores the filter and pages through every song by id.
+func (r *fakeSongRepo) ListSongs(ctx context.Context, list repository.SongListQuery) (*repository.SongList, error) {
+	ids := r.ids()
+	page := list.Page
+
+	var selected []int
+	switch {
+	case page.AfterID > 0:
+		for _, id := range ids {
+			if id > page.AfterID && len(selected) < page.Limit {
+				selected = append(selected, id)
+			}
+		}
+	case page.BeforeID > 0:
+		for i := len(ids) - 1; i >= 0; i-- {
+			if ids[i] < page.BeforeID && len(selected) < page.Limit {
+				selected = append(selected, ids[i])
+			}
+		}
+		slices.Reverse(selected)
+	default:
+		selected = ids[min(page.Offset, len(ids)):min(page.Offset+page.Limit, len(ids))]
+	}
+
+	result := &repository.SongList{}
+	for _, id := range selected {
+		result.Songs = append(result.Songs, r.songs[id])
+	}
+	if list.Total {
+		total := len(ids)
+		result.Total = &total
+	}
+	return result, nil
+}
+
+// GetSongs pages through the songs of filter.Artist by id.
+func (r *fakeSongRepo) GetSongs(ctx context.Context, filter repository.SongFilter, page repository.Page) ([]domain.Song, error) {
+	var songs []domain.Song
+	for _, id := range r.ids() {
+		song := r.songs[id]
+		if id > page.AfterID && strings.EqualFold(song.Group, filter.Artist) && len(songs) < page.Limit {
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+// ModifySongsWhere changes the songs of filter.Artist by id and keeps the
+// changes unless the run is dry.
+func (r *fakeSongRepo) ModifySongsWhere(ctx context.Context, filter repository.SongFilter, opts repository.BulkOptions, fn func(song *domain.Song) error) (*repository.BulkResult, error) {
+	result := &repository.BulkResult{}
+	for _, id := range r.ids() {
+		song := r.songs[id]
+		if !strings.EqualFold(song.Group, filter.Artist) {
+			continue
+		}
+		result.Matched++
+
+		changed := song
+		changed.Tags = slices.Clone(song.Tags)
+		if err := fn(&changed); err != nil {
+			return nil, err
+		}
+		if changed.RawText == song.RawText && changed.Text == song.Text && changed.Group == song.Group && slices.Equal(changed.Tags, song.Tags) {
+			continue
+		}
+		result.Affected++
+		result.SongIDs = append(result.SongIDs, id)
+		if !opts.DryRun {
+			changed.Version++
+			r.songs[id] = changed
+		}
+	}
+	return result, nil
+}
+
+// BeginImport imports into the in-memory songs, matching artist and title
+// case-insensitively.
+func (r *fakeSongRepo) BeginImport(ctx context.Context, opts repository.ImportOptions) (repository.SongImporter, error) {
+	return &fakeImporter{repo: r, opts: opts}, nil
+}
+
+type fakeImporter struct {
+	repo *fakeSongRepo
+	opts repository.ImportOptions
+}
+
+func (i *fakeImporter) ImportBatch(ctx context.Context, songs []domain.Song) ([]repository.ImportResult, error) {
+	results := make([]repository.ImportResult, len(songs))
+	for n, song := range songs {
+		var previous *domain.Song
+		for _, id := range i.repo.ids() {
+			stored := i.repo.songs[id]
+			if strings.EqualFold(stored.Group, song.Group) && strings.EqualFold(stored.Song, song.Song) {
+				previous = &stored
+				break
+			}
+		}
+		if previous != nil && !i.opts.Upsert {
+			results[n] = repository.ImportResult{Status: repository.ImportDuplicate, Song: *previous}
+			continue
+		}
+		if err := i.opts.Prepare(&song, previous); err != nil {
+			results[n] = repository.ImportResult{Status: repository.ImportFailed, Song: song, Err: err}
+			continue
+		}
+
+		status := repository.ImportCreated
+		song.ID, song.Version = len(i.repo.songs)+1, 1
+		if previous != nil {
+			status = repository.ImportUpdated
+			song.ID, song.Version = previous.ID, previous.Version+1
+		}
+		if !i.opts.DryRun {
+			i.repo.songs[song.ID] = song
+		}
+		results[n] = repository.ImportResult{Status: status, Song: song}
+	}
+	return results, nil
+}
+
+func (i *fakeImporter) Close() error {
+	return nil
+}
+
+func newTestSongService(t *testing.T, repo repository.SongRepository) *songService {
+	t.Helper()
+
+	loggers, err := logger.SetupLogger("test")
+	if err != nil {
+		t.Fatalf("SetupLogger: %v", err)
+	}
+	normalizer, err := lyrics.NewNormalizer([]string{lyrics.StepLineEndings, lyrics.StepZeroWidth, lyrics.StepNFC, lyrics.StepQuotes, lyrics.StepTrailingSpace, lyrics.StepBlankLines})
+	if err != nil {
+		t.Fatalf("NewNormalizer: %v", err)
+	}
+	detector := explicit.NewDetector(map[string][]string{"en": {"damn"}})
+
+	return NewSongService(repo, cursor.NewCodec("test-secret"), normalizer, detector, 0, loggers).(*songService)
+}
+
+func songIDs(songs []domain.Song) []int {
+	ids := make([]int, len(songs))
+	for i, song := range songs {
+		ids[i] = song.ID
+	}
+	return ids
+}
+
+func TestGetSongsCursorPaging(t *testing.T) {
+	var songs []domain.Song
+	for id := 1; id <= 5; id++ {
+		songs = append(songs, domain.Song{ID: id})
+	}
+	svc := newTestSongService(t, newFakeSongRepo(songs...))
+	ctx := context.Background()
+
+	first, err := svc.GetSongs(ctx, repository.SongFilter{}, PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if got := songIDs(first.Songs); !slices.Equal(got, []int{1, 2}) || first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("first page = %v next %q prev %q, want [1 2] with only a next cursor", got, first.NextCursor, first.PrevCursor)
+	}
+
+	second, err := svc.GetSongs(ctx, repository.SongFilter{}, PageRequest{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if got := songIDs(second.Songs); !slices.Equal(got, []int{3, 4}) || second.NextCursor == "" || second.PrevCursor == "" {
+		t.Fatalf("second page = %v next %q prev %q, want [3 4] with both cursors", got, second.NextCursor, second.PrevCursor)
+	}
+
+	last, err := svc.GetSongs(ctx, repository.SongFilter{}, PageRequest{Limit: 2, Cursor: second.NextCursor})
+	if err != nil {
+		t.Fatalf("last page: %v", err)
+	}
+	if got := songIDs(last.Songs); !slices.Equal(got, []int{5}) || last.NextCursor != "" || last.PrevCursor == "" {
+		t.Fatalf("last page = %v next %q prev %q, want [5] with only a previous cursor", got, last.NextCursor, last.PrevCursor)
+	}
+
+	back, err := svc.GetSongs(ctx, repository.SongFilter{}, PageRequest{Limit: 2, Cursor: last.PrevCursor})
+	if err != nil {
+		t.Fatalf("previous page: %v", err)
+	}
+	if got := songIDs(back.Songs); !slices.Equal(got, []int{3, 4}) || back.NextCursor == "" || back.PrevCursor == "" {
+		t.Fatalf("previous page = %v next %q prev %q, want [3 4] with both cursors", got, back.NextCursor, back.PrevCursor)
+	}
+
+	front, err := svc.GetSongs(ctx, repository.SongFilter{}, PageRequest{Limit: 2, Cursor: back.PrevCursor})
+	if err != nil {
+		t.Fatalf("front page: %v", err)
+	}
+	if got := songIDs(front.Songs); !slices.Equal(got, []int{1, 2}) || front.PrevCursor != "" {
+		t.Fatalf("front page = %v prev %q, want [1 2] without a previous cursor", got, front.PrevCursor)
+	}
+}
+
+func TestGetSongsOffsetAndTotal(t *testing.T) {
+	svc := newTestSongService(t, newFakeSongRepo(domain.Song{ID: 1}, domain.Song{ID: 2}, domain.Song{ID: 3}))
+
+	page, err := svc.GetSongs(context.Background(), repository.SongFilter{}, PageRequest{Limit: 1, Offset: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatalf("GetSongs: %v", err)
+	}
+	if got := songIDs(page.Songs); !slices.Equal(got, []int{2}) {
+		t.Errorf("songs = %v, want [2]", got)
+	}
+	if page.Total == nil || *page.Total != 3 {
+		t.Errorf("total = %v, want 3", page.Total)
+	}
+	if page.NextCursor == "" || page.PrevCursor == "" {
+		t.Errorf("cursors next %q prev %q, want both", page.NextCursor, page.PrevCursor)
+	}
+}
+
+func TestGetSongsRejectsForgedCursor(t *testing.T) {
+	svc := newTestSongService(t, newFakeSongRepo(domain.Song{ID: 1}))
+	forged := cursor.NewCodec("other-secret").Encode(cursor.Cursor{ID: 1})
+
+	_, err := svc.GetSongs(context.Background(), repository.SongFilter{}, PageRequest{Limit: 1, Cursor: forged})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("GetSongs error = %v, want ErrInvalidCursor", err)
+	}
+}
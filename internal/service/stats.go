@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"music-service/internal/repository"
+	"music-service/pkg/lyrics"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+)
+
+// MaxTopWords is how many frequent words are kept per cached result.
+const MaxTopWords = 100
+
+// ArtistVocabulary is lyrics statistics aggregated over an artist's songs.
+type ArtistVocabulary struct {
+	Artist string `json:"artist"`
+	Songs  int    `json:"songs"`
+	lyrics.Stats
+}
+
+// artistStatsTTL bounds how long an artist's vocabulary is served from the
+// cache. Writes through this process drop it at once, but writes by other
+// replicas, imports and maintenance runs are only noticed when it expires.
+const artistStatsTTL = 5 * time.Minute
+
+// statsCache keeps computed lyrics statistics. Song entries are tagged with
+// the song version they were computed from and only served for that
+// version, so they cannot go stale whoever writes the song. Artist entries
+// cover many songs; an edit can move a song between artists, so they are
+// all dropped on any write here, and expire after artistStatsTTL.
+type statsCache struct {
+	mu         sync.RWMutex
+	songs      map[int]songStats
+	artists    map[string]artistStats
+	generation int
+}
+
+type songStats struct {
+	version int
+	stats   lyrics.Stats
+}
+
+type artistStats struct {
+	expires    time.Time
+	vocabulary ArtistVocabulary
+}
+
+func newStatsCache() *statsCache {
+	return &statsCache{songs: map[int]songStats{}, artists: map[string]artistStats{}}
+}
+
+func (c *statsCache) song(id, version int) (lyrics.Stats, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.songs[id]
+	if !ok || entry.version != version {
+		return lyrics.Stats{}, false
+	}
+	return entry.stats, true
+}
+
+// artist returns the cached vocabulary of an artist, and the generation to
+// pass to putArtist when there is none.
+func (c *statsCache) artist(name string) (ArtistVocabulary, int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.artists[strings.ToLower(name)]
+	if !ok || time.Now().After(entry.expires) {
+		return ArtistVocabulary{}, c.generation, false
+	}
+	return entry.vocabulary, c.generation, true
+}
+
+func (c *statsCache) putSong(id, version int, stats lyrics.Stats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.songs[id]; ok && entry.version > version {
+		return
+	}
+	c.songs[id] = songStats{version: version, stats: stats}
+}
+
+// putArtist caches vocabulary unless a write was seen since generation was
+// read, in which case it may have been computed from stale songs.
+func (c *statsCache) putArtist(name string, generation int, vocabulary ArtistVocabulary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	c.artists[strings.ToLower(name)] = artistStats{expires: time.Now().Add(artistStatsTTL), vocabulary: vocabulary}
+}
+
+func (c *statsCache) invalidate(songID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.songs, songID)
+	clear(c.artists)
+	c.generation++
+}
+
+// GetLyricsStats returns word, line and vocabulary statistics for a song's
+// lyrics. They are cached per song version, so the song is still read to
+// check that the cached statistics are current.
+func (s *songService) GetLyricsStats(ctx context.Context, songID int) (*lyrics.Stats, error) {
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error fetching song for stats", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	if stats, ok := s.stats.song(songID, song.Version); ok {
+		return &stats, nil
+	}
+
+	analyzer := lyrics.NewAnalyzer()
+	analyzer.Add(lyrics.Parse(song.Text), song.Language)
+	stats := analyzer.Stats(MaxTopWords)
+
+	s.stats.putSong(songID, song.Version, stats)
+	return &stats, nil
+}
+
+// GetArtistVocabulary aggregates lyrics statistics over every song by an
+// artist. Repeated lines are only counted within a song. An unknown artist
+// yields a result with no songs.
+func (s *songService) GetArtistVocabulary(ctx context.Context, artist string) (*ArtistVocabulary, error) {
+	vocabulary, generation, ok := s.stats.artist(artist)
+	if ok {
+		return &vocabulary, nil
+	}
+
+	s.logger.DebugLogger.Debug("Entering GetArtistVocabulary service", slog.String("artist", artist))
+
+	vocabulary = ArtistVocabulary{Artist: artist}
+	analyzer := lyrics.NewAnalyzer()
+	page := repository.Page{Limit: exportBatchSize}
+	for {
+		songs, err := s.repo.GetSongs(ctx, repository.SongFilter{Artist: artist}, page)
+		if err != nil {
+			s.logger.ErrorLogger.Error("Error fetching songs for vocabulary", slog.String("artist", artist), slog.Any("error", err))
+			return nil, err
+		}
+
+		for _, song := range songs {
+			analyzer.Add(lyrics.Parse(song.Text), song.Language)
+			vocabulary.Artist = song.Group
+		}
+		vocabulary.Songs += len(songs)
+
+		if len(songs) < exportBatchSize {
+			break
+		}
+		page.AfterID = songs[len(songs)-1].ID
+	}
+
+	vocabulary.Stats = analyzer.Stats(MaxTopWords)
+	if vocabulary.Songs > 0 {
+		s.stats.putArtist(artist, generation, vocabulary)
+	}
+
+	return &vocabulary, nil
+}
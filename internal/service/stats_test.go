@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+	"testing"
+	"time"
+)
+
+func TestLyricsStatsFollowSongVersion(t *testing.T) {
+	repo := newFakeSongRepo(domain.Song{ID: 1, Text: "Sonne"})
+	svc := newTestSongService(t, repo)
+	ctx := context.Background()
+
+	first, err := svc.GetLyricsStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetLyricsStats: %v", err)
+	}
+	if first.Words != 1 {
+		t.Fatalf("words = %d, want 1", first.Words)
+	}
+
+	// A write that bypasses this service, as another replica's would, still
+	// bumps the version and so must not be answered from the cache.
+	song := repo.songs[1]
+	song.Text, song.Version = "Sonne Mond", song.Version+1
+	repo.songs[1] = song
+
+	second, err := svc.GetLyricsStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetLyricsStats: %v", err)
+	}
+	if second.Words != 2 {
+		t.Errorf("words after an outside write = %d, want 2", second.Words)
+	}
+}
+
+func TestStatsCacheKeepsNewestSongVersion(t *testing.T) {
+	cache := newStatsCache()
+	cache.putSong(1, 3, lyrics.Stats{Words: 3})
+	cache.putSong(1, 2, lyrics.Stats{Words: 2})
+
+	if _, ok := cache.song(1, 2); ok {
+		t.Error("a late result for an older version replaced the newer one")
+	}
+	if stats, ok := cache.song(1, 3); !ok || stats.Words != 3 {
+		t.Errorf("song(1, 3) = %+v, %v, want the version 3 stats", stats, ok)
+	}
+}
+
+func TestStatsCacheArtistGeneration(t *testing.T) {
+	cache := newStatsCache()
+
+	_, generation, ok := cache.artist("Rammstein")
+	if ok {
+		t.Fatal("empty cache returned an artist")
+	}
+	cache.invalidate(1)
+	cache.putArtist("Rammstein", generation, ArtistVocabulary{Artist: "Rammstein", Songs: 1})
+	if _, _, ok := cache.artist("rammstein"); ok {
+		t.Error("vocabulary computed before a write was cached")
+	}
+
+	_, generation, _ = cache.artist("Rammstein")
+	cache.putArtist("Rammstein", generation, ArtistVocabulary{Artist: "Rammstein", Songs: 1})
+	if _, _, ok := cache.artist("rammstein"); !ok {
+		t.Error("vocabulary was not cached")
+	}
+
+	entry := cache.artists["rammstein"]
+	entry.expires = time.Now().Add(-time.Second)
+	cache.artists["rammstein"] = entry
+	if _, _, ok := cache.artist("rammstein"); ok {
+		t.Error("expired vocabulary was served")
+	}
+}
+
+func TestArtistVocabularyIsCachedUntilWrite(t *testing.T) {
+	repo := newFakeSongRepo(
+		domain.Song{ID: 1, Group: "Rammstein", Text: "Sonne"},
+		domain.Song{ID: 2, Group: "Rammstein", Text: "Mond"},
+	)
+	svc := newTestSongService(t, repo)
+	ctx := context.Background()
+
+	vocabulary, err := svc.GetArtistVocabulary(ctx, "rammstein")
+	if err != nil {
+		t.Fatalf("GetArtistVocabulary: %v", err)
+	}
+	if vocabulary.Artist != "Rammstein" || vocabulary.Songs != 2 || vocabulary.Words != 2 {
+		t.Fatalf("vocabulary = %+v, want 2 songs of Rammstein with 2 words", vocabulary)
+	}
+
+	delete(repo.songs, 2)
+	if cached, _ := svc.GetArtistVocabulary(ctx, "Rammstein"); cached.Songs != 2 {
+		t.Errorf("songs = %d, want the cached 2", cached.Songs)
+	}
+
+	svc.stats.invalidate(2)
+	if fresh, _ := svc.GetArtistVocabulary(ctx, "Rammstein"); fresh.Songs != 1 {
+		t.Errorf("songs after a write = %d, want 1", fresh.Songs)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"music-service/internal/domain"
+	"music-service/pkg/lrc"
+	"time"
+
+	"log/slog"
+)
+
+// SyncedPosition is the line being sung at a playback position and the one
+// after it. Either may be nil at the start or end of the song.
+type SyncedPosition struct {
+	CurrentIndex int
+	Current      *lrc.Line
+	Next         *lrc.Line
+}
+
+// ImportSyncedLyrics parses an LRC document and stores it, normalized, as
+// the song's synced lyrics. Parse failures are validation errors wrapping
+// *lrc.ParseError.
+func (s *songService) ImportSyncedLyrics(ctx context.Context, songID int, r io.Reader) (*lrc.Lyrics, error) {
+	s.logger.DebugLogger.Debug("Entering ImportSyncedLyrics service", slog.Int("songID", songID))
+
+	parsed, err := lrc.Parse(r)
+	if err != nil {
+		s.logger.ErrorLogger.Error("Invalid LRC document", slog.Int("songID", songID), slog.Any("error", err))
+		var parseErr *lrc.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, domain.Validation(err)
+		}
+		return nil, err
+	}
+
+	// Word-level text keeps its spacing, which the line steps would trim,
+	// so words get only the inline steps.
+	for i := range parsed.Lines {
+		line := &parsed.Lines[i]
+		line.Text = s.normalizer.Normalize(line.Text)
+		for j := range line.Words {
+			line.Words[j].Text = s.normalizer.NormalizeInline(line.Words[j].Text)
+		}
+	}
+
+	if err := s.repo.SaveSyncedLyrics(ctx, songID, parsed.Lines); err != nil {
+		s.logger.ErrorLogger.Error("Error saving synced lyrics", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.InfoLogger.Info("Successfully imported synced lyrics", slog.Int("songID", songID), slog.Int("lines", len(parsed.Lines)))
+	return parsed, nil
+}
+
+// GetSyncedLyrics returns the song's synced lyrics, tagged with its artist
+// and title.
+func (s *songService) GetSyncedLyrics(ctx context.Context, songID int) (*lrc.Lyrics, error) {
+	lines, err := s.repo.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lrc.Lyrics{
+		Tags:  map[string]string{"ar": song.Group, "ti": song.Song},
+		Lines: lines,
+	}, nil
+}
+
+func (s *songService) DeleteSyncedLyrics(ctx context.Context, songID int) error {
+	s.logger.DebugLogger.Debug("Entering DeleteSyncedLyrics service", slog.Int("songID", songID))
+	return s.repo.DeleteSyncedLyrics(ctx, songID)
+}
+
+func (s *songService) GetSyncedLineAt(ctx context.Context, songID int, t time.Duration) (*SyncedPosition, error) {
+	lines, err := s.repo.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := &SyncedPosition{CurrentIndex: lrc.At(lines, t)}
+	if pos.CurrentIndex >= 0 {
+		pos.Current = &lines[pos.CurrentIndex]
+	}
+	if pos.CurrentIndex+1 < len(lines) {
+		pos.Next = &lines[pos.CurrentIndex+1]
+	}
+
+	return pos, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"music-service/internal/domain"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxFieldLength is the length limit of the VARCHAR(255) song columns.
+const maxFieldLength = 255
+
+// earliestReleaseYear predates the first sound recordings; earlier release
+// dates are taken to be typos.
+const earliestReleaseYear = 1860
+
+// ErrMalformedPayload is returned by DecodeJSON for a body that is not a
+// JSON value of the expected shape at all, as opposed to one with invalid
+// fields.
+var ErrMalformedPayload = errors.New("malformed JSON payload")
+
+// DecodeJSON reads a request body into v. Unknown fields and values of the
+// wrong type are reported as a *domain.ValidationError naming the field;
+// other errors wrap ErrMalformedPayload.
+func DecodeJSON(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// DecodeSong reads a song from a JSON request body with DecodeJSON and
+// also pins malformed release dates on their field.
+func DecodeSong(r io.Reader) (domain.Song, error) {
+	var song domain.Song
+	err := DecodeJSON(r, &song)
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return song, &domain.ValidationError{Fields: []domain.FieldError{{
+			Field:   "release_date",
+			Code:    "invalid_format",
+			Message: "must be an RFC 3339 timestamp, e.g. 2006-01-02T00:00:00Z",
+		}}}
+	}
+	return song, err
+}
+
+// decodeError turns an error of json.Decoder.Decode into field errors where
+// it can be pinned on a field.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &domain.ValidationError{Fields: []domain.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("must be of type %s", jsonType(typeErr.Type.Kind().String())),
+		}}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strings.CutPrefix(err.Error(), "json: unknown field ")
+		return &domain.ValidationError{Fields: []domain.FieldError{{
+			Field:   strings.Trim(field, `"`),
+			Code:    "unknown_field",
+			Message: "is not a known field",
+		}}}
+	}
+	return fmt.Errorf("%w: %w", ErrMalformedPayload, err)
+}
+
+// jsonType names a Go kind the way a JSON client would.
+func jsonType(kind string) string {
+	switch kind {
+	case "slice", "array":
+		return "array"
+	case "struct", "map":
+		return "object"
+	case "int", "int64", "float64":
+		return "number"
+	case "bool":
+		return "boolean"
+	}
+	return kind
+}
+
+// validateSong checks the client-supplied fields of a song and reports every
+// invalid one at once.
+func validateSong(song *domain.Song) error {
+	var fields []domain.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, domain.FieldError{Field: field, Code: code, Message: message})
+	}
+
+	for _, f := range []struct{ name, value string }{{"group", song.Group}, {"song", song.Song}} {
+		switch {
+		case strings.TrimSpace(f.value) == "":
+			invalid(f.name, "required", "is required")
+		case utf8.RuneCountInString(f.value) > maxFieldLength:
+			invalid(f.name, "too_long", fmt.Sprintf("must be at most %d characters", maxFieldLength))
+		}
+	}
+
+	if song.Link != "" {
+		if utf8.RuneCountInString(song.Link) > maxFieldLength {
+			invalid("link", "too_long", fmt.Sprintf("must be at most %d characters", maxFieldLength))
+		} else if u, err := url.Parse(song.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("link", "invalid_url", "must be an absolute http or https URL")
+		}
+	}
+
+	switch {
+	case song.ReleaseDate.IsZero():
+		invalid("release_date", "required", "is required")
+	case song.ReleaseDate.Year() < earliestReleaseYear:
+		invalid("release_date", "out_of_range", fmt.Sprintf("must not be before %d", earliestReleaseYear))
+	case song.ReleaseDate.After(time.Now().AddDate(1, 0, 0)):
+		invalid("release_date", "out_of_range", "must not be more than a year in the future")
+	}
+
+	if language := strings.ToLower(strings.TrimSpace(song.Language)); language != "" && !languageCode.MatchString(language) {
+		invalid("language", "invalid_format", "must be a two-letter ISO 639-1 code")
+	}
+
+	for i, tag := range song.Tags {
+		if strings.TrimSpace(tag) == "" {
+			invalid(fmt.Sprintf("tags[%d]", i), "required", "must not be empty")
+		}
+	}
+
+	if len(fields) > 0 {
+		return &domain.ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+
+	"log/slog"
+)
+
+var (
+	ErrVerseHashRequired = domain.Validation(errors.New("the verse's current hash is required to change it"))
+	ErrVerseModified     = domain.Conflict(errors.New("verse was modified by someone else"))
+)
+
+// songStanzas parses lyrics into stanzas carrying their sections and
+// content hashes, as reported to editors.
+func songStanzas(text string) []lyrics.Stanza {
+	stanzas := lyrics.Parse(text)
+	lyrics.DetectStructure(stanzas)
+	for i := range stanzas {
+		stanzas[i].Hash = stanzas[i].ContentHash()
+	}
+	return stanzas
+}
+
+// GetVerse returns one stanza of a song's lyrics with its content hash.
+func (s *songService) GetVerse(ctx context.Context, songID, verse int) (*lyrics.Stanza, error) {
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	stanzas := songStanzas(song.Text)
+	if verse < 0 || verse >= len(stanzas) {
+		return nil, domain.NotFound(lyrics.ErrVerseNotFound)
+	}
+	return &stanzas[verse], nil
+}
+
+// EditLyrics applies a single stanza or line edit to a song's lyrics and
+// returns the resulting stanzas and the index of the stanza the edit left
+// in place, or -1 if it removed one. Edits to an existing stanza only apply
+// if hash matches its current content hash; the check and the write happen
+// in one transaction. Indexes and hashes refer to the normalized lyrics,
+// but the edit is made to the submitted ones, which are normalized again.
+func (s *songService) EditLyrics(ctx context.Context, songID int, hash string, edit lyrics.Edit) ([]lyrics.Stanza, int, error) {
+	s.logger.DebugLogger.Debug("Entering EditLyrics service", slog.Int("songID", songID), slog.String("op", string(edit.Op)), slog.Int("verse", edit.Verse))
+
+	target := -1
+	updated, err := s.repo.ModifySong(ctx, songID, func(song *domain.Song) error {
+		stanzas := lyrics.Parse(song.Text)
+
+		if edit.Op != lyrics.OpInsertVerse {
+			if edit.Verse < 0 || edit.Verse >= len(stanzas) {
+				return domain.NotFound(lyrics.ErrVerseNotFound)
+			}
+			if hash == "" {
+				return ErrVerseHashRequired
+			}
+			if stanzas[edit.Verse].ContentHash() != hash {
+				return ErrVerseModified
+			}
+		}
+
+		edited, err := lyrics.ApplyEdit(stanzas, edit)
+		if err != nil {
+			return editError(err)
+		}
+		target = editedStanza(edit, len(stanzas), len(edited))
+
+		raw := lyrics.Render(edited)
+		if source := rawStanzas(song.RawText, stanzas); source != nil {
+			rawEdited, err := lyrics.ApplyEdit(source, edit)
+			if err != nil {
+				return editError(err)
+			}
+			raw = lyrics.Render(rawEdited)
+		}
+
+		previous := *song
+		song.RawText = raw
+		song.Text = s.normalizer.Normalize(raw)
+		if err := resolveLanguage(song, &previous); err != nil {
+			return err
+		}
+		s.classifyExplicit(song, &previous)
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorLogger.Error("Error editing lyrics", slog.Int("songID", songID), slog.Any("error", err))
+		return nil, -1, err
+	}
+	s.stats.invalidate(songID)
+
+	s.logger.InfoLogger.Info("Successfully edited lyrics", slog.Int("songID", songID), slog.String("op", string(edit.Op)))
+	return songStanzas(updated.Text), target, nil
+}
+
+// rawStanzas parses a song's submitted lyrics so an edit can be applied to
+// them rather than to the normalized text, keeping their original quotes
+// and characters. It returns nil when normalization changed the stanza
+// layout, e.g. by dropping a line of zero-width characters, so the indexes
+// of the normalized stanzas do not address the submitted ones.
+func rawStanzas(raw string, normalized []lyrics.Stanza) []lyrics.Stanza {
+	stanzas := lyrics.Parse(raw)
+	if len(stanzas) != len(normalized) {
+		return nil
+	}
+	for i := range stanzas {
+		if len(stanzas[i].Lines) != len(normalized[i].Lines) {
+			return nil
+		}
+	}
+	return stanzas
+}
+
+// editedStanza returns where the stanza an edit changed or added ends up,
+// given the stanza counts before and after it, or -1 if the edit removed
+// the stanza.
+func editedStanza(edit lyrics.Edit, before, after int) int {
+	switch edit.Op {
+	case lyrics.OpDeleteVerse:
+		return -1
+	case lyrics.OpMoveVerse:
+		return edit.To
+	case lyrics.OpInsertVerse:
+		if edit.Verse < 0 {
+			return after - 1
+		}
+		return edit.Verse
+	}
+	if after < before {
+		// Deleting its last line removed the stanza.
+		return -1
+	}
+	return edit.Verse
+}
+
+// editError gives the errors of lyrics.ApplyEdit their domain kind.
+func editError(err error) error {
+	switch {
+	case errors.Is(err, lyrics.ErrVerseNotFound), errors.Is(err, lyrics.ErrLineNotFound):
+		return domain.NotFound(err)
+	case errors.Is(err, lyrics.ErrInvalidEdit):
+		return domain.Validation(err)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-service/internal/domain"
+	"music-service/pkg/lyrics"
+	"testing"
+)
+
+func TestEditLyricsKeepsSubmittedText(t *testing.T) {
+	raw := "“Hello”  \nWorld\n\n\n[Chorus]\nDon’t stop"
+	repo := newFakeSongRepo(domain.Song{ID: 1, RawText: raw, Text: "\"Hello\"\nWorld\n\n[Chorus]\nDon't stop"})
+	svc := newTestSongService(t, repo)
+
+	hash := lyrics.Parse(repo.songs[1].Text)[0].ContentHash()
+	stanzas, target, err := svc.EditLyrics(context.Background(), 1, hash, lyrics.Edit{Op: lyrics.OpReplaceLine, Verse: 0, Line: 1, Text: "Earth"})
+	if err != nil {
+		t.Fatalf("EditLyrics: %v", err)
+	}
+
+	song := repo.songs[1]
+	if want := "“Hello”\nEarth\n\n[Chorus]\nDon’t stop"; song.RawText != want {
+		t.Errorf("RawText = %q, want %q", song.RawText, want)
+	}
+	if want := "\"Hello\"\nEarth\n\n[Chorus]\nDon't stop"; song.Text != want {
+		t.Errorf("Text = %q, want %q", song.Text, want)
+	}
+	if target != 0 || stanzas[0].Hash != lyrics.Parse(song.Text)[0].ContentHash() {
+		t.Errorf("target %d with hash %q, want stanza 0 with its new hash", target, stanzas[0].Hash)
+	}
+}
+
+func TestEditLyricsFallsBackWhenStanzasDiffer(t *testing.T) {
+	// A line of only a zero-width space is a lyric line of the submitted
+	// text that normalization removes, so line indexes do not carry over.
+	repo := newFakeSongRepo(domain.Song{ID: 1, RawText: "One\n\u200b\nTwo", Text: "One\nTwo"})
+	svc := newTestSongService(t, repo)
+
+	hash := lyrics.Parse("One\nTwo")[0].ContentHash()
+	if _, _, err := svc.EditLyrics(context.Background(), 1, hash, lyrics.Edit{Op: lyrics.OpReplaceLine, Verse: 0, Line: 1, Text: "Three"}); err != nil {
+		t.Fatalf("EditLyrics: %v", err)
+	}
+	if song := repo.songs[1]; song.RawText != "One\nThree" || song.Text != "One\nThree" {
+		t.Errorf("song = %q / %q, want the edited normalized text in both", song.RawText, song.Text)
+	}
+}
+
+func TestEditLyricsChecksHash(t *testing.T) {
+	repo := newFakeSongRepo(domain.Song{ID: 1, RawText: "One", Text: "One"})
+	svc := newTestSongService(t, repo)
+	edit := lyrics.Edit{Op: lyrics.OpReplaceLine, Verse: 0, Line: 0, Text: "Two"}
+
+	tests := []struct {
+		name string
+		hash string
+		want error
+	}{
+		{"missing", "", ErrVerseHashRequired},
+		{"stale", "0123456789abcdef", ErrVerseModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := svc.EditLyrics(context.Background(), 1, tt.hash, edit)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("EditLyrics error = %v, want %v", err, tt.want)
+			}
+			if repo.songs[1].Text != "One" {
+				t.Errorf("Text = %q, want it unchanged", repo.songs[1].Text)
+			}
+		})
+	}
+
+	// Inserting a stanza touches no existing one and needs no hash.
+	if _, _, err := svc.EditLyrics(context.Background(), 1, "", lyrics.Edit{Op: lyrics.OpInsertVerse, Verse: -1, Lines: []string{"Two"}}); err != nil {
+		t.Errorf("insert without hash: %v", err)
+	}
+}
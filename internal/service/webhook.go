@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// errWebhookAddress is returned for webhook hosts that resolve to an
+// address inside the server's own network.
+var errWebhookAddress = errors.New("webhook_url must not point to a loopback, private, link-local or unspecified address")
+
+// NewWebhookClient returns the client webhooks are posted with. It refuses
+// to connect to internal addresses however the host resolves at the time,
+// so a name re-pointed after validation cannot reach them, does not use
+// proxies, and does not follow redirects.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			return checkWebhookAddr(addr)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// validateWebhookURL checks that rawURL is an absolute http(s) URL whose
+// host resolves only to public addresses.
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: webhook_url must be an absolute http(s) URL", ErrInvalidSavedSearch)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: webhook_url host %q does not resolve", ErrInvalidSavedSearch, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if err := checkWebhookAddr(addr); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+		}
+	}
+	return nil
+}
+
+func checkWebhookAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return errWebhookAddress
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS language VARCHAR(16);
+
+CREATE INDEX IF NOT EXISTS songs_tags_idx ON songs USING GIN (tags);
+CREATE INDEX IF NOT EXISTS songs_language_idx ON songs (language);
+
+-- +goose Down
+DROP INDEX IF EXISTS songs_language_idx;
+DROP INDEX IF EXISTS songs_tags_idx;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS tags;
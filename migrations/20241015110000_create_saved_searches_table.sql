@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS songs_created_at_idx ON songs (created_at);
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    query TEXT NOT NULL DEFAULT '',
+    webhook_url VARCHAR(2048),
+    last_checked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_notified_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS saved_searches;
+
+DROP INDEX IF EXISTS songs_created_at_idx;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS created_at;
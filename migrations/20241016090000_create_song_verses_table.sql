@@ -0,0 +1,14 @@
+-- +goose Up
+-- Populated from songs.text by the application; run
+-- `go run ./cmd/maintenance reindex-verses` once after upgrading.
+CREATE TABLE IF NOT EXISTS song_verses (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label VARCHAR(255),
+    start_line INTEGER NOT NULL,
+    lines TEXT[] NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS song_verses;
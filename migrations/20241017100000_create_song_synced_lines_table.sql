@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS song_synced_lines (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    time_ms INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    words JSONB,
+    PRIMARY KEY (song_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS song_synced_lines;
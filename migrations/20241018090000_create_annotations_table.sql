@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS annotations (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    verse INTEGER NOT NULL,
+    line INTEGER,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    quote TEXT NOT NULL,
+    votes INTEGER NOT NULL DEFAULT 0,
+    orphaned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS annotations_song_id_idx ON annotations (song_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS annotations;
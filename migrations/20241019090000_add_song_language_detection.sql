@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS language_confidence REAL,
+    ADD COLUMN IF NOT EXISTS language_source VARCHAR(16);
+
+UPDATE songs SET language_source = 'manual' WHERE language IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS language_source,
+    DROP COLUMN IF EXISTS language_confidence;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Filled in by the application; run
+-- `go run ./cmd/maintenance reindex-verses` once after upgrading.
+ALTER TABLE song_verses
+    ADD COLUMN IF NOT EXISTS section VARCHAR(8),
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS repeat_of INTEGER;
+
+-- +goose Down
+ALTER TABLE song_verses
+    DROP COLUMN IF EXISTS repeat_of,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS section;
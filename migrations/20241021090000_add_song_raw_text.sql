@@ -0,0 +1,7 @@
+-- +goose Up
+-- The lyrics as submitted, before normalization. NULL means text was stored
+-- unchanged; `go run ./cmd/maintenance renormalize` fills it in.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS raw_text TEXT;
+
+-- +goose Down
+ALTER TABLE songs DROP COLUMN IF EXISTS raw_text;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Existing songs are flagged by `go run ./cmd/maintenance detect-explicit`.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS explicit_source VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS explicit_hits JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS songs_explicit_idx ON songs (explicit);
+
+-- +goose Down
+DROP INDEX IF EXISTS songs_explicit_idx;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS explicit_hits,
+    DROP COLUMN IF EXISTS explicit_source,
+    DROP COLUMN IF EXISTS explicit;
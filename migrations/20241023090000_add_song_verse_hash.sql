@@ -0,0 +1,7 @@
+-- +goose Up
+-- Filled in by the application; run
+-- `go run ./cmd/maintenance reindex-verses` once after upgrading.
+ALTER TABLE song_verses ADD COLUMN IF NOT EXISTS hash VARCHAR(16);
+
+-- +goose Down
+ALTER TABLE song_verses DROP COLUMN IF EXISTS hash;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Incremented by every write to a song; exposed as its ETag.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS version;
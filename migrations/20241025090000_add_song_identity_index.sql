@@ -0,0 +1,7 @@
+-- +goose Up
+-- Imports look songs up by artist and title, case-insensitively, to find
+-- duplicates.
+CREATE INDEX IF NOT EXISTS songs_identity_idx ON songs (lower(group_name), lower(song_name));
+
+-- +goose Down
+DROP INDEX IF EXISTS songs_identity_idx;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- One row per bulk change, recording what was asked for and which songs it
+-- touched.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(32) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    affected INTEGER NOT NULL,
+    song_ids INTEGER[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- This migration used to make songs_identity_idx unique, which fails on
+-- databases that already hold duplicate songs. It is kept, doing nothing,
+-- so databases that ran it still know its version;
+-- 20241101090000_restore_song_identity_index undoes it where it ran.
+SELECT 1;
+
+-- +goose Down
+SELECT 1;
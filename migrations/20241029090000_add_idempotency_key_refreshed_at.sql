@@ -0,0 +1,9 @@
+-- +goose Up
+-- refreshed_at is bumped while the request holding a key still runs, so a
+-- retry only takes over the key once its request has stopped.
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS refreshed_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- +goose Down
+ALTER TABLE idempotency_keys
+    DROP COLUMN IF EXISTS refreshed_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Stanza labels come from the lyrics as written, so they are not limited
+-- in length; a long one made storing the whole song's lyrics fail.
+ALTER TABLE song_verses
+    ALTER COLUMN label TYPE TEXT;
+
+-- +goose Down
+ALTER TABLE song_verses
+    ALTER COLUMN label TYPE VARCHAR(255) USING left(label, 255);
@@ -0,0 +1,14 @@
+-- +goose Up
+-- One row per voter and annotation, so a repeated vote replaces the
+-- earlier one instead of adding to the score. Votes cast before voters
+-- were recorded stay in annotations.votes without a row here.
+CREATE TABLE IF NOT EXISTS annotation_votes (
+    annotation_id INTEGER NOT NULL REFERENCES annotations (id) ON DELETE CASCADE,
+    voter VARCHAR(255) NOT NULL,
+    vote SMALLINT NOT NULL CHECK (vote IN (-1, 1)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (annotation_id, voter)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS annotation_votes;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Songs with the same artist and title may legitimately exist, so their
+-- identity index is not unique; imports detect duplicates themselves.
+DROP INDEX IF EXISTS songs_identity_key;
+CREATE INDEX IF NOT EXISTS songs_identity_idx ON songs (lower(group_name), lower(song_name));
+
+-- +goose Down
+SELECT 1;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Webhook notifications remember the id of the last song sent rather than
+-- a time: the notifier pages through new songs in id order, and a creation
+-- time taken from a capped page can pass over songs left out of it.
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS last_notified_id INTEGER NOT NULL DEFAULT 0;
+UPDATE saved_searches s
+SET last_notified_id = COALESCE((SELECT max(id) FROM songs WHERE created_at <= s.last_notified_at), 0);
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS last_notified_at;
+
+-- +goose Down
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS last_notified_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS last_notified_id;
@@ -0,0 +1,66 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in an id-ordered result set. Backward cursors
+// point at the rows preceding ID, forward cursors at the rows following it.
+type Cursor struct {
+	ID       int  `json:"id"`
+	Backward bool `json:"b,omitempty"`
+}
+
+// Codec turns cursors into opaque, HMAC-signed tokens so that clients cannot
+// forge positions by editing them.
+type Codec struct {
+	secret []byte
+}
+
+func NewCodec(secret string) *Codec {
+	return &Codec{secret: []byte(secret)}
+}
+
+func (c *Codec) Encode(cur Cursor) string {
+	payload, _ := json.Marshal(cur)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded))
+}
+
+func (c *Codec) Decode(token string) (Cursor, error) {
+	var cur Cursor
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return cur, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return cur, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &cur); err != nil || cur.ID <= 0 {
+		return cur, ErrInvalidCursor
+	}
+
+	return cur, nil
+}
+
+func (c *Codec) sign(data string) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	codec := NewCodec("secret")
+	for _, cur := range []Cursor{{ID: 1}, {ID: 42, Backward: true}, {ID: 1 << 30}} {
+		got, err := codec.Decode(codec.Encode(cur))
+		if err != nil {
+			t.Fatalf("Decode(Encode(%+v)): %v", cur, err)
+		}
+		if got != cur {
+			t.Errorf("Decode(Encode(%+v)) = %+v", cur, got)
+		}
+	}
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	codec := NewCodec("secret")
+	token := codec.Encode(Cursor{ID: 7})
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"id":8}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"edited payload", forged + "." + signature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"signature not base64", payload + ".!!!"},
+		{"other secret", NewCodec("other").Encode(Cursor{ID: 7})},
+		{"zero id", signedBy(codec, `{"id":0}`)},
+		{"signed non-JSON", signedBy(codec, `not json`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Decode(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
+
+// signedBy builds a correctly signed token around an arbitrary payload.
+func signedBy(codec *Codec, payload string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(codec.sign(encoded))
+}
@@ -0,0 +1,164 @@
+// Package explicit finds words from per-language lists in lyrics and masks
+// them.
+package explicit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"music-service/pkg/lyrics"
+)
+
+// Hit is a listed word found in lyrics: runes [Start, End) of a line of a
+// stanza, all 0-based.
+type Hit struct {
+	Verse int    `json:"verse"`
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Word  string `json:"word"`
+}
+
+// DefaultLists are used when no word list file is configured. An entry
+// ending in "*" matches every word starting with it.
+var DefaultLists = map[string][]string{
+	"en": {
+		"fuck*", "motherfuck*", "shit*", "bullshit*", "bitch*", "asshole*",
+		"cunt*", "dick", "dickhead*", "pussy", "whore*", "slut*",
+	},
+	"de": {
+		"scheiß*", "scheiss*", "fick*", "arschloch*", "hure*", "wichser*",
+		"fotze*", "schlampe*", "hurensohn*", "miststück*",
+	},
+}
+
+// LoadLists reads word lists from a JSON file mapping language codes to
+// words, e.g. {"en": ["word", "prefix*"]}.
+func LoadLists(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists map[string][]string
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, fmt.Errorf("parse explicit word lists %s: %w", path, err)
+	}
+	return lists, nil
+}
+
+type pattern struct {
+	word   string
+	prefix bool
+}
+
+func (p pattern) matches(word string) bool {
+	if p.prefix {
+		return strings.HasPrefix(word, p.word)
+	}
+	return word == p.word
+}
+
+type Detector struct {
+	lists map[string][]pattern
+}
+
+func NewDetector(lists map[string][]string) *Detector {
+	d := &Detector{lists: make(map[string][]pattern, len(lists))}
+	for lang, words := range lists {
+		for _, w := range words {
+			w = strings.ToLower(strings.TrimSpace(w))
+			prefix := strings.HasSuffix(w, "*")
+			w = strings.TrimSuffix(w, "*")
+			if w != "" {
+				d.lists[lang] = append(d.lists[lang], pattern{word: w, prefix: prefix})
+			}
+		}
+	}
+	return d
+}
+
+// patterns returns the list for language, or all lists when the language
+// is unknown or has none.
+func (d *Detector) patterns(language string) []pattern {
+	if list, ok := d.lists[language]; ok {
+		return list
+	}
+
+	var all []pattern
+	for _, list := range d.lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// Detect returns every listed word in the stanzas.
+func (d *Detector) Detect(stanzas []lyrics.Stanza, language string) []Hit {
+	patterns := d.patterns(language)
+	hits := []Hit{}
+	for _, stanza := range stanzas {
+		for l, line := range stanza.Lines {
+			for _, span := range wordSpans(line) {
+				word := strings.ToLower(string([]rune(line)[span[0]:span[1]]))
+				for _, p := range patterns {
+					if p.matches(word) {
+						hits = append(hits, Hit{Verse: stanza.Index, Line: l, Start: span[0], End: span[1], Word: word})
+						break
+					}
+				}
+			}
+		}
+	}
+	return hits
+}
+
+// Mask returns a copy of the stanzas with every listed word replaced by its
+// first letter followed by asterisks.
+func (d *Detector) Mask(stanzas []lyrics.Stanza, language string) []lyrics.Stanza {
+	masked := make([]lyrics.Stanza, len(stanzas))
+	copy(masked, stanzas)
+	for i := range masked {
+		masked[i].Lines = append([]string(nil), stanzas[i].Lines...)
+	}
+
+	positions := make(map[int]int, len(masked))
+	for i, stanza := range masked {
+		positions[stanza.Index] = i
+	}
+
+	for _, hit := range d.Detect(stanzas, language) {
+		lines := masked[positions[hit.Verse]].Lines
+		runes := []rune(lines[hit.Line])
+		for j := hit.Start + 1; j < hit.End; j++ {
+			runes[j] = '*'
+		}
+		lines[hit.Line] = string(runes)
+	}
+	return masked
+}
+
+// wordSpans returns the rune ranges of the words in line. Apostrophes
+// inside a word belong to it.
+func wordSpans(line string) [][2]int {
+	var spans [][2]int
+	runes := []rune(line)
+	start := -1
+	for i, r := range runes {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) ||
+			((r == '\'' || r == '’') && start >= 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]))
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(runes)})
+	}
+	return spans
+}
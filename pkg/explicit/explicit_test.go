@@ -0,0 +1,78 @@
+package explicit
+
+import (
+	"reflect"
+	"testing"
+
+	"music-service/pkg/lyrics"
+)
+
+var testLists = map[string][]string{
+	"en": {"damn", "heck*"},
+	"de": {" Scheiß* ", "mist"},
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		language string
+		want     []Hit
+	}{
+		{"exact word", "Damn it", "en", []Hit{{Start: 0, End: 4, Word: "damn"}}},
+		{"exact word not a prefix", "damnation", "en", []Hit{}},
+		{"prefix", "what the heckin hell", "en", []Hit{{Start: 9, End: 15, Word: "heckin"}}},
+		{"offsets count runes", "Oh, Scheißegal, so ein Mist!", "de", []Hit{{Start: 4, End: 14, Word: "scheißegal"}, {Start: 23, End: 27, Word: "mist"}}},
+		{"apostrophe inside word", "heck's bells, 'damn'", "en", []Hit{{Start: 0, End: 6, Word: "heck's"}, {Start: 15, End: 19, Word: "damn"}}},
+		{"other language list not used", "damn", "de", []Hit{}},
+		{"unknown language uses all lists", "damn mist", "fr", []Hit{{Start: 0, End: 4, Word: "damn"}, {Start: 5, End: 9, Word: "mist"}}},
+		{"clean line", "Hier kommt die Sonne", "de", []Hit{}},
+	}
+
+	detector := NewDetector(testLists)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stanzas := []lyrics.Stanza{{Index: 3, Lines: []string{"first line", tt.line}}}
+			for i := range tt.want {
+				tt.want[i].Verse = 3
+				tt.want[i].Line = 1
+			}
+			if got := detector.Detect(stanzas, tt.language); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		line     string
+		language string
+		want     string
+	}{
+		{"Damn it", "en", "D*** it"},
+		{"Oh, Scheißegal, so ein Mist!", "de", "Oh, S*********, so ein M***!"},
+		{"Ёлки, damn, ёлки", "en", "Ёлки, d***, ёлки"},
+		{"heck's bells", "en", "h***** bells"},
+		{"nothing to hide", "en", "nothing to hide"},
+	}
+
+	detector := NewDetector(testLists)
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			stanzas := lyrics.Parse("[Verse]\n" + tt.line + "\n\n" + tt.line)
+			masked := detector.Mask(stanzas, tt.language)
+			for _, stanza := range masked {
+				if stanza.Lines[0] != tt.want {
+					t.Errorf("Mask(%q) = %q, want %q", tt.line, stanza.Lines[0], tt.want)
+				}
+			}
+			if stanzas[0].Lines[0] != tt.line {
+				t.Errorf("Mask modified its input: %q", stanzas[0].Lines[0])
+			}
+			if masked[0].Label != "Verse" {
+				t.Errorf("Mask dropped the label: %+v", masked[0])
+			}
+		})
+	}
+}
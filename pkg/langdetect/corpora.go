@@ -0,0 +1,84 @@
+package langdetect
+
+// corpora are the sample texts the language profiles are built from. They
+// favour the everyday words and spellings that dominate song lyrics.
+var corpora = map[string]string{
+	"en": `I have been walking down this road for so long, and every night I think
+of you. The wind is cold and the city lights are shining, but my heart is still
+with you. When the morning comes we will find a way to get back home. Tell me
+that you love me, tell me that you need me, and I will never let you go. There
+is nothing in the world that I would not do for you. We were young and we were
+free, we could fly over the water and the mountains. Now the summer is over and
+the leaves are falling, but I remember the words you said. Hold my hand and
+don't be afraid, the thunder is only the sound of the night. Everybody wants to
+rule the world, but all I want is to be with you tonight. The people in the
+street are dancing, and the music never stops. Which way should we go, what
+should we say? I know that tomorrow the sun will rise again.`,
+
+	"de": `Ich bin so lange diesen Weg gegangen, und jede Nacht denke ich an dich.
+Der Wind ist kalt und die Lichter der Stadt leuchten, aber mein Herz ist noch
+bei dir. Wenn der Morgen kommt, werden wir einen Weg nach Hause finden. Sag mir,
+dass du mich liebst, sag mir, dass du mich brauchst, und ich werde dich niemals
+gehen lassen. Es gibt nichts auf der Welt, was ich nicht für dich tun würde.
+Wir waren jung und wir waren frei, wir konnten über das Wasser und die Berge
+fliegen. Jetzt ist der Sommer vorbei und die Blätter fallen, aber ich erinnere
+mich an die Worte, die du gesagt hast. Halt meine Hand und hab keine Angst, der
+Donner ist nur das Geräusch der Nacht. Die Sonne scheint mir aus den Händen,
+kann verbrennen, kann euch blenden. Die Leute auf der Straße tanzen, und die
+Musik hört niemals auf. Welchen Weg sollen wir gehen, was sollen wir sagen?
+Ich weiß, dass morgen die Sonne wieder aufgeht.`,
+
+	"fr": `Je marche sur cette route depuis si longtemps, et chaque nuit je pense à
+toi. Le vent est froid et les lumières de la ville brillent, mais mon cœur est
+encore avec toi. Quand le matin viendra, nous trouverons un chemin pour rentrer
+à la maison. Dis-moi que tu m'aimes, dis-moi que tu as besoin de moi, et je ne
+te laisserai jamais partir. Il n'y a rien au monde que je ne ferais pas pour
+toi. Nous étions jeunes et nous étions libres, nous pouvions voler au-dessus de
+l'eau et des montagnes. Maintenant l'été est fini et les feuilles tombent, mais
+je me souviens des mots que tu as dits. Prends ma main et n'aie pas peur, le
+tonnerre n'est que le bruit de la nuit. Les gens dans la rue dansent, et la
+musique ne s'arrête jamais. Quel chemin devons-nous prendre, que devons-nous
+dire? Je sais que demain le soleil se lèvera encore.`,
+
+	"es": `He caminado por este camino durante tanto tiempo, y cada noche pienso en
+ti. El viento es frío y las luces de la ciudad brillan, pero mi corazón sigue
+contigo. Cuando llegue la mañana encontraremos una manera de volver a casa.
+Dime que me quieres, dime que me necesitas, y nunca te dejaré ir. No hay nada
+en el mundo que no haría por ti. Éramos jóvenes y éramos libres, podíamos volar
+sobre el agua y las montañas. Ahora el verano ha terminado y las hojas caen,
+pero recuerdo las palabras que dijiste. Toma mi mano y no tengas miedo, el
+trueno es solo el sonido de la noche. La gente en la calle está bailando, y la
+música nunca se detiene. ¿Qué camino debemos tomar, qué debemos decir? Yo sé
+que mañana el sol saldrá otra vez.`,
+
+	"it": `Ho camminato su questa strada per tanto tempo, e ogni notte penso a te.
+Il vento è freddo e le luci della città brillano, ma il mio cuore è ancora con
+te. Quando arriverà il mattino troveremo un modo per tornare a casa. Dimmi che
+mi ami, dimmi che hai bisogno di me, e non ti lascerò mai andare. Non c'è niente
+al mondo che non farei per te. Eravamo giovani ed eravamo liberi, potevamo
+volare sopra l'acqua e le montagne. Adesso l'estate è finita e le foglie
+cadono, ma ricordo le parole che hai detto. Prendi la mia mano e non avere
+paura, il tuono è solo il suono della notte. La gente per la strada balla, e la
+musica non si ferma mai. Quale strada dobbiamo prendere, cosa dobbiamo dire?
+So che domani il sole sorgerà ancora.`,
+
+	"ru": `Я так долго иду по этой дороге, и каждую ночь я думаю о тебе. Ветер
+холодный, и огни города сияют, но моё сердце всё ещё с тобой. Когда наступит
+утро, мы найдём дорогу домой. Скажи мне, что ты любишь меня, скажи, что я тебе
+нужен, и я никогда тебя не отпущу. Нет ничего на свете, чего бы я не сделал для
+тебя. Мы были молоды и свободны, мы могли летать над водой и горами. Теперь
+лето прошло и листья падают, но я помню слова, которые ты сказала. Возьми меня
+за руку и не бойся, гром это только звук ночи. Люди на улице танцуют, и музыка
+никогда не смолкает. Какой дорогой нам идти, что нам сказать? Я знаю, что
+завтра солнце снова взойдёт.`,
+
+	"tk": `Men bu ýoldan şeýle uzak wagt ýöredim, her gije seni ýatlaýaryn. Şemal
+sowuk, şäheriň çyralary ýalpyldaýar, emma meniň ýüregim henizem seniň bilen.
+Säher atanda biz öýe dolanmagyň ýoluny taparys. Maňa meni söýýändigiňi aýt,
+maňa meniň gerekdigimi aýt, men seni hiç haçan goýbermerin. Dünýäde seniň üçin
+etmejek zadym ýok. Biz ýaşdyk we azatdyk, biz suwuň we daglaryň üstünden uçup
+bilýärdik. Indi tomus geçdi, ýapraklar gaçýar, ýöne men seniň aýdan
+sözleriňi ýadymda saklaýaryn. Elimden tut we gorkma, ýyldyrym diňe gijäniň
+sesidir. Köçedäki adamlar tans edýärler, aýdym-saz hiç haçan durmaýar. Haýsy
+ýoldan gitmeli, näme diýmeli? Men ertir güneşiň ýene dogjakdygyny bilýärin.`,
+}
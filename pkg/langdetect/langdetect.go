@@ -0,0 +1,156 @@
+// Package langdetect guesses the language of a text offline by comparing
+// its character n-gram profile against profiles of known languages
+// (Cavnar & Trenkle's out-of-place measure).
+package langdetect
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// profileSize is how many of the most frequent n-grams make up a
+	// profile.
+	profileSize = 300
+	// minLetters is the least amount of text worth guessing from.
+	minLetters = 20
+	// minFit is the least share of the worst possible distance by which the
+	// best profile must beat it; text in a language without a profile fits
+	// none of them.
+	minFit = 0.4
+	// minConfidence is the least margin over the runner-up worth reporting.
+	minConfidence = 0.05
+)
+
+// Result is a detected ISO 639-1 language code and a confidence in [0, 1].
+// Language is empty when the text is too short to tell or does not look
+// like any known language.
+type Result struct {
+	Language   string
+	Confidence float64
+}
+
+type profile map[string]int
+
+var (
+	profilesOnce sync.Once
+	profiles     map[string]profile
+)
+
+func loadProfiles() {
+	profiles = make(map[string]profile, len(corpora))
+	for lang, text := range corpora {
+		profiles[lang] = newProfile(text)
+	}
+}
+
+// Languages returns the codes of the languages the detector knows.
+func Languages() []string {
+	langs := make([]string, 0, len(corpora))
+	for lang := range corpora {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Detect returns the known language closest to text. Confidence is the
+// relative margin between the best and the runner-up distance, so it is
+// near 0 when two languages fit about equally well. Text that fits no
+// profile well, or two about equally, is reported as unknown rather than
+// guessed.
+func Detect(text string) Result {
+	profilesOnce.Do(loadProfiles)
+
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minLetters {
+		return Result{}
+	}
+
+	doc := newProfile(text)
+	best, bestDistance, secondDistance := "", -1, -1
+	for _, lang := range Languages() {
+		d := distance(doc, profiles[lang])
+		switch {
+		case bestDistance < 0 || d < bestDistance:
+			best, secondDistance, bestDistance = lang, bestDistance, d
+		case secondDistance < 0 || d < secondDistance:
+			secondDistance = d
+		}
+	}
+
+	if fit := 1 - float64(bestDistance)/float64(len(doc)*profileSize); fit < minFit {
+		return Result{}
+	}
+	if secondDistance <= 0 {
+		return Result{Language: best, Confidence: 1}
+	}
+	confidence := 1 - float64(bestDistance)/float64(secondDistance)
+	if confidence < minConfidence {
+		return Result{}
+	}
+	return Result{Language: best, Confidence: confidence}
+}
+
+// newProfile ranks the 1- to 3-grams of text's words, padded with spaces,
+// by frequency.
+func newProfile(text string) profile {
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= 3; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram != " " {
+					counts[gram]++
+				}
+			}
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for gram := range counts {
+		grams = append(grams, gram)
+	}
+	slices.SortFunc(grams, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+
+	p := make(profile, len(grams))
+	for rank, gram := range grams {
+		p[gram] = rank
+	}
+	return p
+}
+
+// distance sums how far each n-gram of doc is from its rank in lang, with
+// n-grams lang does not have counting as the maximum.
+func distance(doc, lang profile) int {
+	total := 0
+	for gram, rank := range doc {
+		langRank, ok := lang[gram]
+		if !ok {
+			total += profileSize
+			continue
+		}
+		if rank > langRank {
+			total += rank - langRank
+		} else {
+			total += langRank - rank
+		}
+	}
+	return total
+}
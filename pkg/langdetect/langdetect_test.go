@@ -0,0 +1,52 @@
+package langdetect
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "Hold me close and never let me go, the night is young and so are we", "en"},
+		{"german", "Hier kommt die Sonne, sie ist der hellste Stern von allen", "de"},
+		{"french", "Je ne regrette rien, ni le bien qu'on m'a fait, ni le mal", "fr"},
+		{"spanish", "Quiero bailar contigo toda la noche bajo las estrellas del cielo", "es"},
+		{"italian", "Nel blu dipinto di blu, felice di stare lassù con te", "it"},
+		{"russian", "Перемен требуют наши сердца, перемен требуют наши глаза", "ru"},
+		{"turkmen", "Gözel watanym, seniň daglaryň we çölleriň meniň ýüregimde", "tk"},
+		{"too short", "la la la", ""},
+		{"no letters", "1234 5678 !!! ??? 1234 5678 ... 1234 5678", ""},
+		{"unknown script", "東京の夜は長くて、君のことを思い出す。雨が降っている街で一人歩く。", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Language != tt.want {
+				t.Errorf("Detect(%q) = %+v, want language %q", tt.text, got, tt.want)
+			}
+			if got.Confidence < 0 || got.Confidence > 1 {
+				t.Errorf("Detect(%q) confidence %v is outside [0, 1]", tt.text, got.Confidence)
+			}
+			if got.Language == "" && got.Confidence != 0 {
+				t.Errorf("Detect(%q) reports confidence %v without a language", tt.text, got.Confidence)
+			}
+		})
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	if !slices.IsSorted(languages) {
+		t.Errorf("Languages() = %v, want sorted", languages)
+	}
+	for code := range corpora {
+		if !slices.Contains(languages, code) {
+			t.Errorf("Languages() = %v, missing %q", languages, code)
+		}
+	}
+}
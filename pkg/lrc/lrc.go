@@ -0,0 +1,264 @@
+// Package lrc reads and writes LRC synced lyrics, including the enhanced
+// format with word-level <mm:ss.xx> tags.
+package lrc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Word is a word-level timing from an enhanced LRC line.
+type Word struct {
+	Time time.Duration
+	Text string
+}
+
+// Line is a single timed lyric line. Words is empty for plain LRC.
+type Line struct {
+	Time  time.Duration
+	Text  string
+	Words []Word
+}
+
+// Lyrics is a parsed LRC file. Tags holds ID tags such as "ar" and "ti";
+// the offset tag is applied to the timestamps while parsing.
+type Lyrics struct {
+	Tags  map[string]string
+	Lines []Line
+}
+
+// ParseError reports a problem on a specific 1-based line of the input.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("lrc: line %d: %s", e.Line, e.Msg)
+}
+
+const (
+	// maxLineSize is the longest input line Parse accepts, in bytes.
+	maxLineSize = 64 << 10
+
+	// maxTime bounds timestamps and the offset tag, so that no timing can
+	// overflow a time.Duration.
+	maxTime = 1000 * time.Minute
+)
+
+var (
+	timestampRe = regexp.MustCompile(`^(\d+):(\d{1,2})(?:[.:](\d{1,3}))?$`)
+	idTagRe     = regexp.MustCompile(`^([a-zA-Z#]+):(.*)$`)
+	wordTagRe   = regexp.MustCompile(`<([^<>]*)>`)
+)
+
+// Parse reads an LRC document. A line with several timestamps is repeated
+// at each of them, and the lines are returned sorted by time. Word timings
+// must not decrease within a line.
+func Parse(r io.Reader) (*Lyrics, error) {
+	result := &Lyrics{Tags: map[string]string{}}
+	var offset time.Duration
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if raw == "" {
+			continue
+		}
+
+		var stamps []time.Duration
+		rest := raw
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, &ParseError{Line: lineNo, Msg: "unterminated '['"}
+			}
+			tag := rest[1:end]
+
+			if t, ok := parseTimestamp(tag); ok {
+				stamps = append(stamps, t)
+				rest = rest[end+1:]
+				continue
+			}
+
+			if timestampRe.MatchString(tag) {
+				return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("timestamp [%s] is out of range", tag)}
+			}
+
+			m := idTagRe.FindStringSubmatch(tag)
+			if m == nil || len(stamps) > 0 || strings.TrimSpace(rest[end+1:]) != "" {
+				return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("invalid tag [%s]", tag)}
+			}
+
+			key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+			if key == "offset" {
+				ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+				if err != nil || ms < -int(maxTime.Milliseconds()) || ms > int(maxTime.Milliseconds()) {
+					return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("invalid offset %q", value)}
+				}
+				offset = time.Duration(ms) * time.Millisecond
+			} else {
+				result.Tags[key] = value
+			}
+			rest = ""
+		}
+
+		if len(stamps) == 0 {
+			if rest != "" {
+				return nil, &ParseError{Line: lineNo, Msg: "missing timestamp"}
+			}
+			continue
+		}
+
+		// Word timings belong to the first timestamp; the repeats of the
+		// line are delayed by the distance to theirs.
+		for _, stamp := range stamps {
+			line := Line{Time: shift(stamp, offset)}
+			if err := parseWords(&line, rest, stamp-stamps[0], offset); err != nil {
+				return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+			}
+			result.Lines = append(result.Lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, &ParseError{Line: lineNo + 1, Msg: fmt.Sprintf("line is longer than %d bytes", maxLineSize)}
+		}
+		return nil, err
+	}
+
+	sort.SliceStable(result.Lines, func(i, j int) bool { return result.Lines[i].Time < result.Lines[j].Time })
+	return result, nil
+}
+
+// parseWords fills in the text of a line and, for enhanced LRC, its word
+// timings, each delayed by delay.
+func parseWords(line *Line, text string, delay, offset time.Duration) error {
+	tags := wordTagRe.FindAllStringSubmatchIndex(text, -1)
+	if len(tags) == 0 {
+		line.Text = strings.TrimSpace(text)
+		return nil
+	}
+
+	var plain []string
+	if lead := strings.TrimSpace(text[:tags[0][0]]); lead != "" {
+		line.Words = append(line.Words, Word{Time: line.Time, Text: lead})
+		plain = append(plain, lead)
+	}
+
+	previous := line.Time
+	for i, tag := range tags {
+		t, ok := parseTimestamp(text[tag[2]:tag[3]])
+		if !ok {
+			return fmt.Errorf("invalid word timestamp <%s>", text[tag[2]:tag[3]])
+		}
+		t = shift(t+delay, offset)
+		if t < previous {
+			return fmt.Errorf("word timestamp %s is earlier than %s", FormatTimestamp(t), FormatTimestamp(previous))
+		}
+		previous = t
+
+		end := len(text)
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+		}
+		word := strings.TrimSpace(text[tag[1]:end])
+		if word == "" && i+1 < len(tags) {
+			continue
+		}
+		// A trailing tag without text marks when the last word ends.
+		line.Words = append(line.Words, Word{Time: t, Text: word})
+		if word != "" {
+			plain = append(plain, word)
+		}
+	}
+
+	line.Text = strings.Join(plain, " ")
+	return nil
+}
+
+func parseTimestamp(s string) (time.Duration, bool) {
+	m := timestampRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	minutes, err := strconv.Atoi(m[1])
+	if err != nil || minutes >= int(maxTime/time.Minute) {
+		return 0, false
+	}
+	seconds, _ := strconv.Atoi(m[2])
+	if seconds >= 60 {
+		return 0, false
+	}
+
+	t := time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if frac := m[3]; frac != "" {
+		ms, _ := strconv.Atoi((frac + "00")[:3])
+		t += time.Duration(ms) * time.Millisecond
+	}
+
+	return t, true
+}
+
+func shift(t, offset time.Duration) time.Duration {
+	// A positive offset makes lyrics appear sooner.
+	t -= offset
+	if t < 0 {
+		return 0
+	}
+	return t
+}
+
+// FormatTimestamp renders t as mm:ss.xx.
+func FormatTimestamp(t time.Duration) string {
+	cs := t.Milliseconds() / 10
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// Write renders lyrics as LRC. ID tags are written first, in sorted order.
+func Write(w io.Writer, lyrics *Lyrics) error {
+	bw := bufio.NewWriter(w)
+
+	keys := make([]string, 0, len(lyrics.Tags))
+	for key := range lyrics.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(bw, "[%s:%s]\n", key, lyrics.Tags[key])
+	}
+
+	for _, line := range lyrics.Lines {
+		fmt.Fprintf(bw, "[%s]", FormatTimestamp(line.Time))
+		if len(line.Words) == 0 {
+			bw.WriteString(line.Text)
+		}
+		for i, word := range line.Words {
+			if i > 0 {
+				bw.WriteByte(' ')
+			}
+			fmt.Fprintf(bw, "<%s>%s", FormatTimestamp(word.Time), word.Text)
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// At returns the index of the line being sung at position t, or -1 before
+// the first line.
+func At(lines []Line, t time.Duration) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].Time > t }) - 1
+}
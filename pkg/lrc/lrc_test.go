@@ -0,0 +1,133 @@
+package lrc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"plain", "[ar:Rammstein]\n[ti:Sonne]\n[00:01.50]Eins\n[00:02.00]Zwei\n[01:03.25]Aus\n"},
+		{"enhanced", "[00:10.00]<00:10.00>Hier <00:10.50>kommt <00:11.20>die <00:11.80>Sonne\n[00:12.00]<00:12.00>Eins\n"},
+		{"no tags", "[00:00.00]\n[00:05.10]Only line\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := Parse(strings.NewReader(tt.doc))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := Write(&buf, first); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if buf.String() != tt.doc {
+				t.Errorf("Write(Parse(doc)) =\n%s\nwant\n%s", buf.String(), tt.doc)
+			}
+
+			second, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("Parse of written document: %v", err)
+			}
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("round trip changed lyrics:\n%+v\n%+v", first, second)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	lyrics, err := Parse(strings.NewReader("\ufeff[offset:+500]\n[00:01.5]<00:01.50>a <00:02.00>b <00:02.50>\n[00:03:25]c\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Line{
+		{Time: time.Second, Text: "a b", Words: []Word{
+			{Time: time.Second, Text: "a"},
+			{Time: 1500 * time.Millisecond, Text: "b"},
+			{Time: 2 * time.Second, Text: ""},
+		}},
+		{Time: 2750 * time.Millisecond, Text: "c"},
+	}
+	if !reflect.DeepEqual(lyrics.Lines, want) {
+		t.Errorf("Lines = %+v, want %+v", lyrics.Lines, want)
+	}
+}
+
+func TestParseRepeatsAndSorts(t *testing.T) {
+	lyrics, err := Parse(strings.NewReader("[00:05.00]c\n[00:01.00][00:03.00]<00:01.00>a <00:01.50>b\n[00:02.00]x\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Line{
+		{Time: time.Second, Text: "a b", Words: []Word{{Time: time.Second, Text: "a"}, {Time: 1500 * time.Millisecond, Text: "b"}}},
+		{Time: 2 * time.Second, Text: "x"},
+		{Time: 3 * time.Second, Text: "a b", Words: []Word{{Time: 3 * time.Second, Text: "a"}, {Time: 3500 * time.Millisecond, Text: "b"}}},
+		{Time: 5 * time.Second, Text: "c"},
+	}
+	if !reflect.DeepEqual(lyrics.Lines, want) {
+		t.Errorf("Lines = %+v, want %+v", lyrics.Lines, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		line int
+	}{
+		{"unterminated tag", "[00:01.00]a\n[00:02.00\n", 2},
+		{"missing timestamp", "just text\n", 1},
+		{"invalid tag", "[00:01.00][ar:x]\n", 1},
+		{"seconds out of range", "[00:61.00]a\n", 1},
+		{"minutes out of range", "[00:01.00]a\n[99999999999999999999:00.00]b\n", 2},
+		{"invalid offset", "[offset:soon]\n", 1},
+		{"offset out of range", "[ar:x]\n[offset:9223372036854775]\n", 2},
+		{"decreasing words", "[00:01.00]<00:02.00>a <00:01.50>b\n", 1},
+		{"invalid word timestamp", "[00:01.00]<later>a\n", 1},
+		{"line too long", "[00:01.00]a\n[00:02.00]" + strings.Repeat("x", maxLineSize) + "\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.doc))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse error = %v, want *ParseError", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("error line = %d, want %d (%v)", parseErr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	lines := []Line{{Time: time.Second}, {Time: 3 * time.Second}, {Time: 3 * time.Second}, {Time: 5 * time.Second}}
+	tests := []struct {
+		at   time.Duration
+		want int
+	}{
+		{0, -1},
+		{time.Second, 0},
+		{2 * time.Second, 0},
+		{3 * time.Second, 2},
+		{time.Minute, 3},
+	}
+	for _, tt := range tests {
+		if got := At(lines, tt.at); got != tt.want {
+			t.Errorf("At(%v) = %d, want %d", tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package lyrics
+
+import (
+	"strings"
+)
+
+// Anchor points at a range of lyrics: runes [Start, End) of one line of a
+// stanza, or of the whole stanza (lines joined by "\n") when Line is nil.
+type Anchor struct {
+	Verse int  `json:"verse"`
+	Line  *int `json:"line,omitempty"`
+	Start int  `json:"start"`
+	End   int  `json:"end"`
+}
+
+// target returns the text an anchor's offsets refer to.
+func (a Anchor) target(stanzas []Stanza) ([]rune, bool) {
+	if a.Verse < 0 || a.Verse >= len(stanzas) {
+		return nil, false
+	}
+	stanza := stanzas[a.Verse]
+
+	if a.Line == nil {
+		return []rune(strings.Join(stanza.Lines, "\n")), true
+	}
+	if *a.Line < 0 || *a.Line >= len(stanza.Lines) {
+		return nil, false
+	}
+	return []rune(stanza.Lines[*a.Line]), true
+}
+
+// Text returns the anchored text, or false if the anchor does not fit the
+// lyrics.
+func (a Anchor) Text(stanzas []Stanza) (string, bool) {
+	target, ok := a.target(stanzas)
+	if !ok || a.Start < 0 || a.Start >= a.End || a.End > len(target) {
+		return "", false
+	}
+	return string(target[a.Start:a.End]), true
+}
+
+// Whole returns the anchor widened to cover its entire line or stanza.
+func (a Anchor) Whole(stanzas []Stanza) Anchor {
+	target, _ := a.target(stanzas)
+	a.Start, a.End = 0, len(target)
+	return a
+}
+
+// Reanchor finds quote in edited lyrics, preferring the anchor's old
+// position, then the occurrence nearest to it. It reports false when the
+// quote no longer appears anywhere.
+func Reanchor(stanzas []Stanza, a Anchor, quote string) (Anchor, bool) {
+	if text, ok := a.Text(stanzas); ok && text == quote {
+		return a, true
+	}
+
+	needle := []rune(quote)
+	if len(needle) == 0 {
+		return a, false
+	}
+
+	best, bestDistance := a, -1
+	consider := func(candidate Anchor) {
+		target, _ := candidate.target(stanzas)
+		for i := 0; i+len(needle) <= len(target); i++ {
+			if string(target[i:i+len(needle)]) != quote {
+				continue
+			}
+			candidate.Start, candidate.End = i, i+len(needle)
+			if d := anchorDistance(a, candidate); bestDistance < 0 || d < bestDistance {
+				best, bestDistance = candidate, d
+			}
+		}
+	}
+
+	for v, stanza := range stanzas {
+		if a.Line == nil {
+			consider(Anchor{Verse: v})
+			continue
+		}
+		for l := range stanza.Lines {
+			line := l
+			consider(Anchor{Verse: v, Line: &line})
+		}
+	}
+
+	return best, bestDistance >= 0
+}
+
+// anchorDistance orders candidate positions by how far they moved: stanza
+// first, then line, then character offset.
+func anchorDistance(from, to Anchor) int {
+	abs := func(n int) int {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+
+	d := abs(from.Verse-to.Verse) * 1_000_000
+	if from.Line != nil && to.Line != nil {
+		d += abs(*from.Line-*to.Line) * 1_000
+	}
+	return d + min(abs(from.Start-to.Start), 999)
+}
@@ -0,0 +1,202 @@
+package lyrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLabelLength is the longest stanza label an edit may set, in runes.
+const MaxLabelLength = 100
+
+var (
+	ErrVerseNotFound = errors.New("verse not found")
+	ErrLineNotFound  = errors.New("line not found")
+	ErrInvalidEdit   = errors.New("invalid lyrics edit")
+)
+
+// EditOp names a change to a single stanza or line.
+type EditOp string
+
+const (
+	OpReplaceVerse EditOp = "replace_verse"
+	OpInsertVerse  EditOp = "insert_verse"
+	OpDeleteVerse  EditOp = "delete_verse"
+	OpMoveVerse    EditOp = "move_verse"
+	OpReplaceLine  EditOp = "replace_line"
+	OpInsertLine   EditOp = "insert_line"
+	OpDeleteLine   EditOp = "delete_line"
+)
+
+// Edit is a change to one stanza. Verse and Line are 0-based positions;
+// for inserts they are where the new stanza or line ends up, with a
+// negative position meaning at the end. To is the destination of a move.
+// A nil Label leaves a replaced stanza's label as it was.
+type Edit struct {
+	Op    EditOp
+	Verse int
+	Line  int
+	To    int
+	Label *string
+	Lines []string
+	Text  string
+}
+
+// ContentHash identifies a stanza's label and lines, so an editor can tell
+// whether the stanza changed since they read it.
+func (s Stanza) ContentHash() string {
+	sum := sha256.Sum256([]byte(s.Label + "\x00" + strings.Join(s.Lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Render turns stanzas back into lyrics text that Parse reads as the same
+// stanzas: labels as "[Label]" lines, stanzas separated by a blank line.
+func Render(stanzas []Stanza) string {
+	var b strings.Builder
+	for i, stanza := range stanzas {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if stanza.Label != "" {
+			b.WriteString("[" + stanza.Label + "]\n")
+		}
+		b.WriteString(strings.Join(stanza.Lines, "\n"))
+	}
+	return b.String()
+}
+
+// ApplyEdit returns the stanzas with the edit applied. The result is
+// re-parsed from its rendered text, so indexes and line numbers are
+// consistent.
+func ApplyEdit(stanzas []Stanza, e Edit) ([]Stanza, error) {
+	stanzas = cloneStanzas(stanzas)
+
+	inVerse := func(n int) bool { return n >= 0 && n < len(stanzas) }
+	if e.Op != OpInsertVerse && !inVerse(e.Verse) {
+		return nil, ErrVerseNotFound
+	}
+
+	switch e.Op {
+	case OpReplaceVerse, OpInsertVerse:
+		if err := validateLines(e.Lines); err != nil {
+			return nil, err
+		}
+		stanza := Stanza{Lines: e.Lines}
+		if e.Label != nil {
+			stanza.Label = strings.TrimSpace(*e.Label)
+			if err := validateLabel(stanza.Label); err != nil {
+				return nil, err
+			}
+		}
+		if e.Op == OpReplaceVerse {
+			if e.Label == nil {
+				stanza.Label = stanzas[e.Verse].Label
+			}
+			stanzas[e.Verse] = stanza
+			break
+		}
+		if e.Verse < 0 {
+			e.Verse = len(stanzas)
+		}
+		if e.Verse > len(stanzas) {
+			return nil, fmt.Errorf("%w: verse position %d is out of range", ErrInvalidEdit, e.Verse)
+		}
+		stanzas = append(stanzas[:e.Verse], append([]Stanza{stanza}, stanzas[e.Verse:]...)...)
+
+	case OpDeleteVerse:
+		stanzas = append(stanzas[:e.Verse], stanzas[e.Verse+1:]...)
+
+	case OpMoveVerse:
+		if !inVerse(e.To) {
+			return nil, fmt.Errorf("%w: target position %d is out of range", ErrInvalidEdit, e.To)
+		}
+		moved := stanzas[e.Verse]
+		stanzas = append(stanzas[:e.Verse], stanzas[e.Verse+1:]...)
+		stanzas = append(stanzas[:e.To], append([]Stanza{moved}, stanzas[e.To:]...)...)
+
+	case OpReplaceLine, OpInsertLine, OpDeleteLine:
+		lines := stanzas[e.Verse].Lines
+		limit := len(lines)
+		if e.Op == OpInsertLine {
+			limit++
+			if e.Line < 0 {
+				e.Line = len(lines)
+			}
+		}
+		if e.Line < 0 || e.Line >= limit {
+			return nil, ErrLineNotFound
+		}
+
+		switch e.Op {
+		case OpReplaceLine, OpInsertLine:
+			if err := validateLines([]string{e.Text}); err != nil {
+				return nil, err
+			}
+			if e.Op == OpReplaceLine {
+				lines[e.Line] = e.Text
+			} else {
+				lines = append(lines[:e.Line], append([]string{e.Text}, lines[e.Line:]...)...)
+			}
+		case OpDeleteLine:
+			lines = append(lines[:e.Line], lines[e.Line+1:]...)
+		}
+
+		if len(lines) == 0 {
+			stanzas = append(stanzas[:e.Verse], stanzas[e.Verse+1:]...)
+		} else {
+			stanzas[e.Verse].Lines = lines
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidEdit, e.Op)
+	}
+
+	for _, stanza := range stanzas {
+		if err := validateLines(stanza.Lines); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(Render(stanzas)), nil
+}
+
+// validateLabel rejects labels that would not survive a render and parse
+// round trip, or that are too long.
+func validateLabel(label string) error {
+	if strings.ContainsAny(label, "[]\r\n") {
+		return fmt.Errorf("%w: labels must be single-line and must not contain brackets", ErrInvalidEdit)
+	}
+	if utf8.RuneCountInString(label) > MaxLabelLength {
+		return fmt.Errorf("%w: labels must be at most %d characters", ErrInvalidEdit, MaxLabelLength)
+	}
+	return nil
+}
+
+// validateLines rejects lines that would not survive a render and parse
+// round trip as lines of one stanza.
+func validateLines(lines []string) error {
+	if len(lines) == 0 {
+		return fmt.Errorf("%w: a verse needs at least one line", ErrInvalidEdit)
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.ContainsAny(line, "\r\n") {
+			return fmt.Errorf("%w: lines must be non-empty and single-line", ErrInvalidEdit)
+		}
+		if _, ok := sectionLabel(trimmed); ok {
+			return fmt.Errorf("%w: line %q looks like a section label", ErrInvalidEdit, trimmed)
+		}
+	}
+	return nil
+}
+
+func cloneStanzas(stanzas []Stanza) []Stanza {
+	clone := make([]Stanza, len(stanzas))
+	for i, stanza := range stanzas {
+		clone[i] = stanza
+		clone[i].Lines = append([]string(nil), stanza.Lines...)
+	}
+	return clone
+}
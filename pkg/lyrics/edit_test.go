@@ -0,0 +1,115 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"single stanza", "Eins\nZwei\nDrei"},
+		{"labelled stanzas", "[Verse 1]\nHier kommt die Sonne\n\n[Chorus]\nSonne\nSonne"},
+		{"unlabelled after labelled", "[Intro]\nLa la\n\nNo label here"},
+		{"indented lines", "  Leading spaces\n\tTabbed"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stanzas := Parse(tt.text)
+			if got := Render(stanzas); got != tt.text {
+				t.Errorf("Render(Parse(text)) = %q, want %q", got, tt.text)
+			}
+			if again := Parse(Render(stanzas)); !reflect.DeepEqual(again, stanzas) {
+				t.Errorf("Parse(Render(stanzas)) = %+v, want %+v", again, stanzas)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	stanzas := Parse("[Verse]\r\nOne  \nTwo\n\n\n[Chorus]\n\nThree\n[Bridge]\nFour")
+	want := []Stanza{
+		{Index: 0, Label: "Verse", StartLine: 1, Lines: []string{"One", "Two"}},
+		{Index: 1, Label: "Chorus", StartLine: 3, Lines: []string{"Three"}},
+		{Index: 2, Label: "Bridge", StartLine: 4, Lines: []string{"Four"}},
+	}
+	if !reflect.DeepEqual(stanzas, want) {
+		t.Errorf("Parse = %+v, want %+v", stanzas, want)
+	}
+}
+
+func TestApplyEdit(t *testing.T) {
+	const text = "[Verse]\nA1\nA2\n\n[Chorus]\nB1\n\nC1\nC2"
+	label := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		edit Edit
+		want string
+	}{
+		{"replace verse keeps label", Edit{Op: OpReplaceVerse, Verse: 1, Lines: []string{"X"}}, "[Verse]\nA1\nA2\n\n[Chorus]\nX\n\nC1\nC2"},
+		{"replace verse clears label", Edit{Op: OpReplaceVerse, Verse: 1, Label: label(""), Lines: []string{"X"}}, "[Verse]\nA1\nA2\n\nX\n\nC1\nC2"},
+		{"insert verse at start", Edit{Op: OpInsertVerse, Verse: 0, Label: label(" Intro "), Lines: []string{"X"}}, "[Intro]\nX\n\n[Verse]\nA1\nA2\n\n[Chorus]\nB1\n\nC1\nC2"},
+		{"insert verse at end", Edit{Op: OpInsertVerse, Verse: -1, Lines: []string{"X"}}, text + "\n\nX"},
+		{"delete verse", Edit{Op: OpDeleteVerse, Verse: 0}, "[Chorus]\nB1\n\nC1\nC2"},
+		{"move verse", Edit{Op: OpMoveVerse, Verse: 0, To: 2}, "[Chorus]\nB1\n\nC1\nC2\n\n[Verse]\nA1\nA2"},
+		{"replace line", Edit{Op: OpReplaceLine, Verse: 2, Line: 1, Text: "X"}, "[Verse]\nA1\nA2\n\n[Chorus]\nB1\n\nC1\nX"},
+		{"insert line at end", Edit{Op: OpInsertLine, Verse: 0, Line: -1, Text: "X"}, "[Verse]\nA1\nA2\nX\n\n[Chorus]\nB1\n\nC1\nC2"},
+		{"delete last line drops stanza", Edit{Op: OpDeleteLine, Verse: 1, Line: 0}, "[Verse]\nA1\nA2\n\nC1\nC2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stanzas := Parse(text)
+			got, err := ApplyEdit(stanzas, tt.edit)
+			if err != nil {
+				t.Fatalf("ApplyEdit: %v", err)
+			}
+			if rendered := Render(got); rendered != tt.want {
+				t.Errorf("ApplyEdit result = %q, want %q", rendered, tt.want)
+			}
+			if Render(stanzas) != text {
+				t.Error("ApplyEdit modified its input")
+			}
+		})
+	}
+}
+
+func TestApplyEditErrors(t *testing.T) {
+	const text = "[Verse]\nA1\nA2\n\nB1"
+	label := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		edit Edit
+		want error
+	}{
+		{"verse out of range", Edit{Op: OpDeleteVerse, Verse: 2}, ErrVerseNotFound},
+		{"negative verse", Edit{Op: OpReplaceLine, Verse: -1, Text: "X"}, ErrVerseNotFound},
+		{"line out of range", Edit{Op: OpReplaceLine, Verse: 0, Line: 2, Text: "X"}, ErrLineNotFound},
+		{"insert verse past end", Edit{Op: OpInsertVerse, Verse: 3, Lines: []string{"X"}}, ErrInvalidEdit},
+		{"move out of range", Edit{Op: OpMoveVerse, Verse: 0, To: 2}, ErrInvalidEdit},
+		{"no lines", Edit{Op: OpReplaceVerse, Verse: 0}, ErrInvalidEdit},
+		{"blank line", Edit{Op: OpReplaceLine, Verse: 0, Text: "  "}, ErrInvalidEdit},
+		{"multi-line text", Edit{Op: OpInsertLine, Verse: 0, Text: "X\nY"}, ErrInvalidEdit},
+		{"line looks like a label", Edit{Op: OpReplaceVerse, Verse: 1, Lines: []string{"[Chorus]"}}, ErrInvalidEdit},
+		{"label with bracket", Edit{Op: OpReplaceVerse, Verse: 0, Label: label("Verse]"), Lines: []string{"X"}}, ErrInvalidEdit},
+		{"label with newline", Edit{Op: OpInsertVerse, Verse: 0, Label: label("A\nB"), Lines: []string{"X"}}, ErrInvalidEdit},
+		{"label too long", Edit{Op: OpInsertVerse, Verse: 0, Label: label(strings.Repeat("x", MaxLabelLength+1)), Lines: []string{"X"}}, ErrInvalidEdit},
+		{"unknown op", Edit{Op: "rename", Verse: 0}, ErrInvalidEdit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ApplyEdit(Parse(text), tt.edit); !errors.Is(err, tt.want) {
+				t.Errorf("ApplyEdit error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
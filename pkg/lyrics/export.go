@@ -0,0 +1,195 @@
+package lyrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is a lyrics export format.
+type Format string
+
+const (
+	FormatText     Format = "txt"
+	FormatMarkdown Format = "md"
+	FormatSRT      Format = "srt"
+	FormatVTT      Format = "vtt"
+	FormatJSON     Format = "json"
+)
+
+// estimatedLineDuration is how long each line is shown in subtitle exports
+// of songs without synced lyrics.
+const estimatedLineDuration = 3 * time.Second
+
+// Cue is a timed piece of lyrics for subtitle formats.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Document is everything needed to export a song's lyrics. Cues come from
+// synced lyrics when the song has them.
+type Document struct {
+	Artist  string   `json:"artist"`
+	Title   string   `json:"title"`
+	Stanzas []Stanza `json:"stanzas"`
+	Cues    []Cue    `json:"cues,omitempty"`
+}
+
+func (c Cue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}{c.Start.Seconds(), c.End.Seconds(), c.Text})
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatMarkdown, FormatSRT, FormatVTT, FormatJSON:
+		return f, nil
+	case "text", "plain":
+		return FormatText, nil
+	case "markdown":
+		return FormatMarkdown, nil
+	case "webvtt":
+		return FormatVTT, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", s)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatJSON:
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Extension is the file extension for the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Export writes the document in the given format.
+func Export(w io.Writer, doc Document, format Format) error {
+	switch format {
+	case FormatText:
+		return writeText(w, doc)
+	case FormatMarkdown:
+		return writeMarkdown(w, doc)
+	case FormatSRT:
+		return writeSubtitles(w, doc, false)
+	case FormatVTT:
+		return writeSubtitles(w, doc, true)
+	case FormatJSON:
+		if doc.Stanzas == nil {
+			doc.Stanzas = []Stanza{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+// writeText renders the lyrics with [Label] section markers, which Parse
+// reads back.
+func writeText(w io.Writer, doc Document) error {
+	var b strings.Builder
+	for i, stanza := range doc.Stanzas {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if stanza.Label != "" {
+			fmt.Fprintf(&b, "[%s]\n", stanza.Label)
+		}
+		for _, line := range stanza.Lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `#`, `\#`, `<`, `\<`, `>`, `\>`,
+)
+
+func writeMarkdown(w io.Writer, doc Document) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(doc.Title))
+	if doc.Artist != "" {
+		fmt.Fprintf(&b, "*%s*\n", markdownEscaper.Replace(doc.Artist))
+	}
+
+	for _, stanza := range doc.Stanzas {
+		b.WriteString("\n")
+		if stanza.Label != "" {
+			fmt.Fprintf(&b, "## %s\n\n", markdownEscaper.Replace(stanza.Label))
+		}
+		for i, line := range stanza.Lines {
+			b.WriteString(markdownEscaper.Replace(line))
+			if i < len(stanza.Lines)-1 {
+				b.WriteString("  ") // hard line break
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSubtitles(w io.Writer, doc Document, vtt bool) error {
+	cues := doc.Cues
+	if len(cues) == 0 {
+		cues = estimateCues(doc.Stanzas)
+	}
+
+	var b strings.Builder
+	if vtt {
+		b.WriteString("WEBVTT\n\n")
+	}
+	for i, cue := range cues {
+		if vtt {
+			fmt.Fprintf(&b, "%s --> %s\n", formatCueTime(cue.Start, '.'), formatCueTime(cue.End, '.'))
+		} else {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n", i+1, formatCueTime(cue.Start, ','), formatCueTime(cue.End, ','))
+		}
+		b.WriteString(cue.Text + "\n\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// estimateCues spaces lines evenly, leaving a one-line gap between stanzas.
+func estimateCues(stanzas []Stanza) []Cue {
+	var cues []Cue
+	var at time.Duration
+	for i, stanza := range stanzas {
+		if i > 0 {
+			at += estimatedLineDuration
+		}
+		for _, line := range stanza.Lines {
+			cues = append(cues, Cue{Start: at, End: at + estimatedLineDuration, Text: line})
+			at += estimatedLineDuration
+		}
+	}
+	return cues
+}
+
+func formatCueTime(t time.Duration, sep byte) string {
+	ms := t.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,53 @@
+package lyrics
+
+import (
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldOptions controls which differences are ignored when comparing text.
+type FoldOptions struct {
+	Case       bool
+	Diacritics bool
+}
+
+// fold normalizes s for matching. It returns the folded runes together with
+// the index of the original rune each folded rune came from, so matches can
+// be reported against the original text. Runs of whitespace collapse into a
+// single space.
+func fold(s string, opts FoldOptions) ([]rune, []int) {
+	var folded []rune
+	var origin []int
+
+	for i, r := range []rune(s) {
+		if unicode.IsSpace(r) {
+			if len(folded) > 0 && folded[len(folded)-1] == ' ' {
+				continue
+			}
+			folded = append(folded, ' ')
+			origin = append(origin, i)
+			continue
+		}
+
+		parts := []rune{r}
+		if opts.Diacritics {
+			parts = parts[:0]
+			for _, p := range norm.NFD.String(string(r)) {
+				if !unicode.Is(unicode.Mn, p) {
+					parts = append(parts, p)
+				}
+			}
+		}
+
+		for _, p := range parts {
+			if opts.Case {
+				p = unicode.ToLower(p)
+			}
+			folded = append(folded, p)
+			origin = append(origin, i)
+		}
+	}
+
+	return folded, origin
+}
@@ -0,0 +1,132 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization steps, in the order they are applied.
+const (
+	StepLineEndings   = "line_endings"
+	StepZeroWidth     = "zero_width"
+	StepNFC           = "nfc"
+	StepQuotes        = "quotes"
+	StepTrailingSpace = "trailing_space"
+	StepBlankLines    = "blank_lines"
+)
+
+// normalizeSteps lists every step; inline steps leave whitespace and line
+// structure alone, so they also apply to fragments of a line.
+var normalizeSteps = []struct {
+	name   string
+	fn     func(string) string
+	inline bool
+}{
+	{StepLineEndings, normalizeLineEndings, false},
+	{StepZeroWidth, stripZeroWidth, true},
+	{StepNFC, norm.NFC.String, true},
+	{StepQuotes, straightenQuotes, true},
+	{StepTrailingSpace, trimTrailingSpace, false},
+	{StepBlankLines, collapseBlankLines, false},
+}
+
+// Normalizer cleans up lyrics text with a chosen set of steps.
+type Normalizer struct {
+	steps  []func(string) string
+	inline []func(string) string
+}
+
+// NewNormalizer enables the named steps. They always run in a fixed order
+// (line endings first, blank lines last) whatever order they are listed in.
+func NewNormalizer(steps []string) (*Normalizer, error) {
+	enabled := map[string]bool{}
+	for _, step := range steps {
+		enabled[strings.TrimSpace(step)] = true
+	}
+
+	n := &Normalizer{}
+	for _, step := range normalizeSteps {
+		if enabled[step.name] {
+			n.steps = append(n.steps, step.fn)
+			if step.inline {
+				n.inline = append(n.inline, step.fn)
+			}
+			delete(enabled, step.name)
+		}
+	}
+	delete(enabled, "")
+	for name := range enabled {
+		return nil, fmt.Errorf("unknown lyrics normalization step %q", name)
+	}
+
+	return n, nil
+}
+
+func (n *Normalizer) Normalize(text string) string {
+	for _, step := range n.steps {
+		text = step(text)
+	}
+	return text
+}
+
+// NormalizeInline applies only the enabled inline steps, for text such as
+// a timed word whose surrounding spaces are significant.
+func (n *Normalizer) NormalizeInline(text string) string {
+	for _, step := range n.inline {
+		text = step(text)
+	}
+	return text
+}
+
+func normalizeLineEndings(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+func stripZeroWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff', '\u00ad': // zero-width, word joiner, BOM, soft hyphen
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var quoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+)
+
+func straightenQuotes(s string) string {
+	return quoteReplacer.Replace(s)
+}
+
+func trimTrailingSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseBlankLines keeps at most one blank line between stanzas and drops
+// blank lines at the start and end.
+func collapseBlankLines(s string) string {
+	var out []string
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,55 @@
+package lyrics
+
+import "testing"
+
+var allSteps = []string{StepLineEndings, StepZeroWidth, StepNFC, StepQuotes, StepTrailingSpace, StepBlankLines}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []string
+		in    string
+		want  string
+	}{
+		{"line endings", []string{StepLineEndings}, "a\r\nb\rc", "a\nb\nc"},
+		{"zero width", []string{StepZeroWidth}, "so\u00adn\u200bne\ufeff", "sonne"},
+		{"nfc", []string{StepNFC}, "Cafe\u0301", "Caf\u00e9"},
+		{"quotes", []string{StepQuotes}, "„Don’t“ ‚go‘", `"Don't" 'go'`},
+		{"trailing space", []string{StepTrailingSpace}, "a  \nb\t\n c", "a\nb\n c"},
+		{"blank lines", []string{StepBlankLines}, "\n\na\n\n \n\nb\n\n", "a\n\nb"},
+		{"all steps", allSteps, "\r\n“Hi”  \r\n\r\n\r\nthere\u200b\r\n", "\"Hi\"\n\nthere"},
+		{"no steps", nil, "“Hi”\r\n", "“Hi”\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNormalizer(tt.steps)
+			if err != nil {
+				t.Fatalf("NewNormalizer: %v", err)
+			}
+			if got := n.Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInline(t *testing.T) {
+	n, err := NewNormalizer(allSteps)
+	if err != nil {
+		t.Fatalf("NewNormalizer: %v", err)
+	}
+
+	if got, want := n.NormalizeInline(" don’t\u200b "), " don't "; got != want {
+		t.Errorf("NormalizeInline = %q, want %q: surrounding spaces must survive", got, want)
+	}
+}
+
+func TestNewNormalizerRejectsUnknownStep(t *testing.T) {
+	if _, err := NewNormalizer([]string{StepNFC, "lowercase"}); err == nil {
+		t.Error("NewNormalizer accepted an unknown step")
+	}
+	if _, err := NewNormalizer([]string{"", " nfc "}); err != nil {
+		t.Errorf("NewNormalizer with blank and padded names: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package lyrics
+
+import (
+	"strings"
+)
+
+// Unit selects what lyrics are paginated by.
+type Unit string
+
+const (
+	UnitStanza Unit = "stanza"
+	UnitLine   Unit = "line"
+)
+
+// Stanza is a block of lyric lines separated from its neighbours by blank
+// lines. Label holds the section marker that introduced it, e.g. "Chorus"
+// for "[Chorus]". StartLine is the 1-based number of the stanza's first line
+// counting lyric lines only. Section, Role and RepeatOf are set by
+// DetectStructure; RepeatOf is the index of the first stanza of the same
+// section. Hash is the stanza's ContentHash where it is reported to
+// editors.
+type Stanza struct {
+	Index     int      `json:"index"`
+	Label     string   `json:"label,omitempty"`
+	StartLine int      `json:"start_line"`
+	Lines     []string `json:"lines"`
+	Section   string   `json:"section,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	RepeatOf  *int     `json:"repeat_of,omitempty"`
+	Hash      string   `json:"hash,omitempty"`
+}
+
+// Parse splits lyrics into stanzas. Blank lines end a stanza; a line of the
+// form "[Label]" ends the current stanza and labels the next one.
+func Parse(text string) []Stanza {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var stanzas []Stanza
+	var current *Stanza
+	label := ""
+	lineNo := 0
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRightFunc(line, isSpace)
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			current = nil
+			continue
+		}
+
+		if l, ok := sectionLabel(trimmed); ok {
+			current = nil
+			label = l
+			continue
+		}
+
+		if current == nil {
+			stanzas = append(stanzas, Stanza{Index: len(stanzas), Label: label, StartLine: lineNo + 1})
+			current = &stanzas[len(stanzas)-1]
+			label = ""
+		}
+
+		lineNo++
+		current.Lines = append(current.Lines, line)
+	}
+
+	return stanzas
+}
+
+func sectionLabel(line string) (string, bool) {
+	if len(line) < 3 || line[0] != '[' || line[len(line)-1] != ']' {
+		return "", false
+	}
+	label := strings.TrimSpace(line[1 : len(line)-1])
+	return label, label != ""
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r'
+}
+
+// Paginate returns the stanzas covering [offset, offset+limit) in the given
+// unit. When paginating by line, stanzas cut by the page boundary are
+// returned partially, with StartLine adjusted to their first returned line.
+func Paginate(stanzas []Stanza, unit Unit, limit, offset int) []Stanza {
+	if unit != UnitLine {
+		if offset >= len(stanzas) {
+			return nil
+		}
+		return stanzas[offset:min(offset+limit, len(stanzas))]
+	}
+
+	var page []Stanza
+	for _, stanza := range stanzas {
+		first := stanza.StartLine - 1
+		last := first + len(stanza.Lines)
+		from, to := max(first, offset), min(last, offset+limit)
+		if from >= to {
+			continue
+		}
+
+		part := stanza
+		part.StartLine = from + 1
+		part.Lines = stanza.Lines[from-first : to-first]
+		page = append(page, part)
+	}
+
+	return page
+}
+
+// LineCount returns the number of lyric lines across all stanzas.
+func LineCount(stanzas []Stanza) int {
+	n := 0
+	for _, stanza := range stanzas {
+		n += len(stanza.Lines)
+	}
+	return n
+}
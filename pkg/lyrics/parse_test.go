@@ -0,0 +1,48 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	stanzas := Parse("One\nTwo\n\nThree\n\nFour\nFive\nSix")
+
+	tests := []struct {
+		name          string
+		unit          Unit
+		limit, offset int
+		want          []Stanza
+	}{
+		{"first stanzas", UnitStanza, 2, 0, stanzas[:2]},
+		{"last stanza", UnitStanza, 5, 2, stanzas[2:]},
+		{"stanza offset past end", UnitStanza, 2, 3, nil},
+		{"lines across stanzas", UnitLine, 3, 1, []Stanza{
+			{Index: 0, StartLine: 2, Lines: []string{"Two"}},
+			{Index: 1, StartLine: 3, Lines: []string{"Three"}},
+			{Index: 2, StartLine: 4, Lines: []string{"Four"}},
+		}},
+		{"lines within a stanza", UnitLine, 1, 4, []Stanza{
+			{Index: 2, StartLine: 5, Lines: []string{"Five"}},
+		}},
+		{"line offset past end", UnitLine, 2, 6, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Paginate(stanzas, tt.unit, tt.limit, tt.offset)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Paginate(%s, %d, %d) = %+v, want %+v", tt.unit, tt.limit, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	if got := LineCount(Parse("One\nTwo\n\n[Chorus]\nThree")); got != 3 {
+		t.Errorf("LineCount = %d, want 3", got)
+	}
+	if got := LineCount(nil); got != 0 {
+		t.Errorf("LineCount(nil) = %d, want 0", got)
+	}
+}
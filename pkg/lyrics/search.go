@@ -0,0 +1,60 @@
+package lyrics
+
+import (
+	"slices"
+	"strings"
+)
+
+// Match is one occurrence of a search term in the lyrics. Verse is the index
+// of the stanza and Line the 1-based lyric line number (see Stanza). Start
+// and End are rune offsets into the matched line, End being exclusive.
+type Match struct {
+	Verse int    `json:"verse"`
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Search finds every non-overlapping occurrence of term in the lyrics. The
+// term is matched as a phrase within a line: its words must appear in order,
+// separated by any amount of whitespace.
+func Search(stanzas []Stanza, term string, opts FoldOptions) []Match {
+	needle, _ := fold(strings.TrimSpace(term), opts)
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for _, stanza := range stanzas {
+		for i, line := range stanza.Lines {
+			matches = append(matches, searchLine(line, needle, opts, stanza.Index, stanza.StartLine+i)...)
+		}
+	}
+
+	return matches
+}
+
+func searchLine(line string, needle []rune, opts FoldOptions, verse, lineNo int) []Match {
+	haystack, origin := fold(line, opts)
+
+	var matches []Match
+	for start := 0; start+len(needle) <= len(haystack); {
+		if !slices.Equal(haystack[start:start+len(needle)], needle) {
+			start++
+			continue
+		}
+
+		end := start + len(needle)
+		matches = append(matches, Match{
+			Verse: verse,
+			Line:  lineNo,
+			Start: origin[start],
+			End:   origin[end-1] + 1,
+			Text:  line,
+		})
+		start = end
+	}
+
+	return matches
+}
@@ -0,0 +1,58 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	stanzas := Parse("Hier kommt die Sonne\nDie   SONNE scheint\n\nÜber den Wolken\nueber uber")
+
+	tests := []struct {
+		name string
+		term string
+		opts FoldOptions
+		want []Match
+	}{
+		{"exact case", "Sonne", FoldOptions{}, []Match{
+			{Verse: 0, Line: 1, Start: 15, End: 20, Text: "Hier kommt die Sonne"},
+		}},
+		{"ignore case", "sonne", FoldOptions{Case: true}, []Match{
+			{Verse: 0, Line: 1, Start: 15, End: 20, Text: "Hier kommt die Sonne"},
+			{Verse: 0, Line: 2, Start: 6, End: 11, Text: "Die   SONNE scheint"},
+		}},
+		{"phrase across spaces", "die sonne", FoldOptions{Case: true}, []Match{
+			{Verse: 0, Line: 1, Start: 11, End: 20, Text: "Hier kommt die Sonne"},
+			{Verse: 0, Line: 2, Start: 0, End: 11, Text: "Die   SONNE scheint"},
+		}},
+		{"diacritics kept", "uber", FoldOptions{Case: true}, []Match{
+			{Verse: 1, Line: 4, Start: 6, End: 10, Text: "ueber uber"},
+		}},
+		{"ignore diacritics", "uber", FoldOptions{Case: true, Diacritics: true}, []Match{
+			{Verse: 1, Line: 3, Start: 0, End: 4, Text: "Über den Wolken"},
+			{Verse: 1, Line: 4, Start: 6, End: 10, Text: "ueber uber"},
+		}},
+		{"no match", "mond", FoldOptions{Case: true}, nil},
+		{"blank term", "  ", FoldOptions{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Search(stanzas, tt.term, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %+v, want %+v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchDoesNotOverlap(t *testing.T) {
+	got := Search(Parse("aaaa"), "aa", FoldOptions{})
+	want := []Match{
+		{Verse: 0, Line: 1, Start: 0, End: 2, Text: "aaaa"},
+		{Verse: 0, Line: 1, Start: 2, End: 4, Text: "aaaa"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search = %+v, want %+v", got, want)
+	}
+}
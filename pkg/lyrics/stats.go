@@ -0,0 +1,135 @@
+package lyrics
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// WordCount is a word and how often it occurs.
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Stats summarizes the text of one or more songs. UniqueRatio is distinct
+// words over total words; RepetitionRatio is the share of lines that repeat
+// an earlier line of the same song. TopWords excludes stop words.
+type Stats struct {
+	Words           int         `json:"words"`
+	UniqueWords     int         `json:"unique_words"`
+	Lines           int         `json:"lines"`
+	Stanzas         int         `json:"stanzas"`
+	UniqueRatio     float64     `json:"unique_ratio"`
+	RepetitionRatio float64     `json:"repetition_ratio"`
+	AvgLineLength   float64     `json:"avg_line_length"`
+	TopWords        []WordCount `json:"top_words"`
+}
+
+// Analyzer accumulates word and line counts over songs.
+type Analyzer struct {
+	counts        map[string]int
+	stopWords     map[string]bool
+	words         int
+	lines         int
+	repeatedLines int
+	lineRunes     int
+	stanzas       int
+}
+
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{counts: map[string]int{}, stopWords: map[string]bool{}}
+}
+
+// Add counts the stanzas of one song. Stop words of the song's language are
+// left out of the top words; an unknown language drops the stop words of
+// every language we have a list for.
+func (a *Analyzer) Add(stanzas []Stanza, language string) {
+	for _, word := range StopWords(language) {
+		a.stopWords[word] = true
+	}
+
+	seen := map[string]bool{}
+	for _, stanza := range stanzas {
+		a.stanzas++
+		for _, line := range stanza.Lines {
+			a.lines++
+			a.lineRunes += len([]rune(line))
+
+			words := Words(line)
+			key := strings.Join(words, " ")
+			if seen[key] {
+				a.repeatedLines++
+			}
+			seen[key] = true
+
+			for _, word := range words {
+				a.words++
+				a.counts[word]++
+			}
+		}
+	}
+}
+
+// Stats returns the totals so far with up to top most frequent words.
+func (a *Analyzer) Stats(top int) Stats {
+	stats := Stats{
+		Words:       a.words,
+		UniqueWords: len(a.counts),
+		Lines:       a.lines,
+		Stanzas:     a.stanzas,
+		TopWords:    []WordCount{},
+	}
+	if a.words > 0 {
+		stats.UniqueRatio = float64(len(a.counts)) / float64(a.words)
+	}
+	if a.lines > 0 {
+		stats.RepetitionRatio = float64(a.repeatedLines) / float64(a.lines)
+		stats.AvgLineLength = float64(a.lineRunes) / float64(a.lines)
+	}
+
+	for word, count := range a.counts {
+		if !a.stopWords[word] {
+			stats.TopWords = append(stats.TopWords, WordCount{Word: word, Count: count})
+		}
+	}
+	slices.SortFunc(stats.TopWords, func(x, y WordCount) int {
+		if x.Count != y.Count {
+			return y.Count - x.Count
+		}
+		return strings.Compare(x.Word, y.Word)
+	})
+	if len(stats.TopWords) > top {
+		stats.TopWords = stats.TopWords[:top]
+	}
+
+	return stats
+}
+
+// Words splits a line into lower-cased words. Apostrophes inside a word
+// ("don't", "geht's") are kept.
+func Words(line string) []string {
+	var words []string
+	var word []rune
+
+	runes := []rune(line)
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, unicode.ToLower(r))
+			continue
+		case (r == '\'' || r == '’') && len(word) > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			word = append(word, '\'')
+			continue
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
@@ -0,0 +1,65 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"Hier kommt die Sonne!", []string{"hier", "kommt", "die", "sonne"}},
+		{"Don't stop, geht’s los", []string{"don't", "stop", "geht's", "los"}},
+		{"'quoted' - 99 luftballons", []string{"quoted", "99", "luftballons"}},
+		{"...", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Words(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.Add(Parse("The sun, the sun\nThe sun\n\nThe sun"), "en")
+
+	got := analyzer.Stats(10)
+	want := Stats{
+		Words:           8,
+		UniqueWords:     2,
+		Lines:           3,
+		Stanzas:         2,
+		UniqueRatio:     0.25,
+		RepetitionRatio: 1.0 / 3,
+		AvgLineLength:   (16 + 7 + 7) / 3.0,
+		TopWords:        []WordCount{{Word: "sun", Count: 4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzerAcrossSongs(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.Add(Parse("Sonne\nMond"), "de")
+	analyzer.Add(Parse("Sonne"), "de")
+
+	got := analyzer.Stats(1)
+	if got.RepetitionRatio != 0 {
+		t.Errorf("RepetitionRatio = %v, want 0: lines only repeat within a song", got.RepetitionRatio)
+	}
+	if want := []WordCount{{Word: "sonne", Count: 2}}; !reflect.DeepEqual(got.TopWords, want) {
+		t.Errorf("TopWords = %+v, want %+v", got.TopWords, want)
+	}
+}
+
+func TestAnalyzerEmpty(t *testing.T) {
+	got := NewAnalyzer().Stats(5)
+	if want := (Stats{TopWords: []WordCount{}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
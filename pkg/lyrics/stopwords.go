@@ -0,0 +1,36 @@
+package lyrics
+
+// stopWords holds, per ISO 639-1 code, common words that say nothing about
+// a song's vocabulary.
+var stopWords = map[string][]string{
+	"en": {
+		"a", "about", "all", "am", "an", "and", "are", "as", "at", "be", "but", "by",
+		"can", "do", "don't", "for", "from", "get", "got", "have", "he", "her", "him",
+		"his", "i", "i'm", "if", "in", "is", "it", "it's", "just", "me", "my", "no",
+		"not", "of", "oh", "on", "or", "out", "she", "so", "that", "the", "their",
+		"them", "then", "there", "they", "this", "to", "up", "was", "we", "what",
+		"when", "will", "with", "you", "you're", "your", "yeah",
+	},
+	"de": {
+		"aber", "alle", "als", "am", "an", "auch", "auf", "aus", "bei", "bin", "bis",
+		"bist", "da", "das", "dass", "dein", "deine", "dem", "den", "der", "des",
+		"die", "dich", "dir", "doch", "du", "ein", "eine", "einen", "er", "es", "für",
+		"hat", "ich", "ihr", "im", "in", "ist", "ja", "kein", "mein", "meine", "mich",
+		"mir", "mit", "nicht", "noch", "nur", "oh", "sich", "sie", "sind", "so",
+		"und", "uns", "von", "was", "wenn", "wie", "wir", "zu", "zum",
+	},
+}
+
+// StopWords returns the stop words of a language, or of every known
+// language when it has no list.
+func StopWords(language string) []string {
+	if words, ok := stopWords[language]; ok {
+		return words
+	}
+
+	var all []string
+	for _, words := range stopWords {
+		all = append(all, words...)
+	}
+	return all
+}
@@ -0,0 +1,212 @@
+package lyrics
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Section roles assigned to repeated stanzas.
+const (
+	RoleChorus  = "chorus"
+	RoleRefrain = "refrain"
+)
+
+const (
+	// stanzaSimilarity is how alike two stanzas must be to count as the
+	// same section.
+	stanzaSimilarity = 0.85
+	// lineSimilarity is how alike two lines must be to count as a repeat.
+	lineSimilarity = 0.9
+)
+
+// Section is a group of stanzas with (nearly) the same text.
+type Section struct {
+	Name    string `json:"name"`
+	Role    string `json:"role,omitempty"`
+	Stanzas []int  `json:"stanzas"`
+}
+
+// LinePosition is a line of a stanza, both 0-based.
+type LinePosition struct {
+	Verse int `json:"verse"`
+	Line  int `json:"line"`
+}
+
+// RepeatedLine is a line that recurs, nearly unchanged, across different
+// sections — a refrain candidate.
+type RepeatedLine struct {
+	Text      string         `json:"text"`
+	Positions []LinePosition `json:"positions"`
+}
+
+// Structure describes how a song's stanzas repeat. Pattern names each
+// stanza's section in order, e.g. "A B C B D B".
+type Structure struct {
+	Pattern       string         `json:"pattern"`
+	Sections      []Section      `json:"sections"`
+	RepeatedLines []RepeatedLine `json:"repeated_lines"`
+}
+
+// DetectStructure groups stanzas whose normalized text is nearly the same
+// into sections and sets each stanza's Section, Role and RepeatOf. The most
+// repeated section is the chorus candidate and other repeated sections are
+// refrain candidates, unless a [Chorus] or [Refrain] label says otherwise.
+func DetectStructure(stanzas []Stanza) Structure {
+	structure := Structure{Sections: []Section{}, RepeatedLines: []RepeatedLine{}}
+
+	var grams []map[string]int
+	for i := range stanzas {
+		g := bigrams(normalize(strings.Join(stanzas[i].Lines, " ")))
+
+		section := -1
+		for s, sec := range structure.Sections {
+			if dice(g, grams[sec.Stanzas[0]]) >= stanzaSimilarity {
+				section = s
+				break
+			}
+		}
+		grams = append(grams, g)
+
+		if section < 0 {
+			section = len(structure.Sections)
+			structure.Sections = append(structure.Sections, Section{Name: sectionName(section)})
+		} else {
+			first := structure.Sections[section].Stanzas[0]
+			stanzas[i].RepeatOf = &first
+		}
+		structure.Sections[section].Stanzas = append(structure.Sections[section].Stanzas, i)
+	}
+
+	assignRoles(stanzas, structure.Sections)
+
+	names := make([]string, len(stanzas))
+	for _, sec := range structure.Sections {
+		for _, i := range sec.Stanzas {
+			stanzas[i].Section = sec.Name
+			stanzas[i].Role = sec.Role
+			names[i] = sec.Name
+		}
+	}
+	structure.Pattern = strings.Join(names, " ")
+	structure.RepeatedLines = repeatedLines(stanzas)
+
+	return structure
+}
+
+func assignRoles(stanzas []Stanza, sections []Section) {
+	labelled := false
+	for s := range sections {
+		for _, i := range sections[s].Stanzas {
+			label := strings.ToLower(stanzas[i].Label)
+			switch {
+			case strings.Contains(label, "chorus"):
+				sections[s].Role = RoleChorus
+			case strings.Contains(label, "refrain"):
+				sections[s].Role = RoleRefrain
+			}
+		}
+		labelled = labelled || sections[s].Role == RoleChorus
+	}
+
+	chorus := -1
+	for s, sec := range sections {
+		if len(sec.Stanzas) < 2 || sec.Role != "" {
+			continue
+		}
+		if !labelled && (chorus < 0 || len(sec.Stanzas) > len(sections[chorus].Stanzas)) {
+			chorus = s
+		}
+		sections[s].Role = RoleRefrain
+	}
+	if chorus >= 0 {
+		sections[chorus].Role = RoleChorus
+	}
+}
+
+// repeatedLines finds lines that recur in stanzas of different sections.
+// Repeats inside a repeated section are already covered by the section.
+func repeatedLines(stanzas []Stanza) []RepeatedLine {
+	type line struct {
+		pos   LinePosition
+		text  string
+		grams map[string]int
+	}
+
+	var lines []line
+	for v, stanza := range stanzas {
+		for l, text := range stanza.Lines {
+			if norm := normalize(text); norm != "" {
+				lines = append(lines, line{LinePosition{v, l}, text, bigrams(norm)})
+			}
+		}
+	}
+
+	repeated := []RepeatedLine{}
+	used := make([]bool, len(lines))
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		group := RepeatedLine{Text: lines[i].text, Positions: []LinePosition{lines[i].pos}}
+		sections := map[string]bool{stanzas[lines[i].pos.Verse].Section: true}
+		for j := i + 1; j < len(lines); j++ {
+			if !used[j] && dice(lines[i].grams, lines[j].grams) >= lineSimilarity {
+				used[j] = true
+				group.Positions = append(group.Positions, lines[j].pos)
+				sections[stanzas[lines[j].pos.Verse].Section] = true
+			}
+		}
+		if len(sections) > 1 {
+			repeated = append(repeated, group)
+		}
+	}
+
+	return repeated
+}
+
+// sectionName returns A, B, ... Z, then A2, B2 and so on.
+func sectionName(i int) string {
+	name := string(rune('A' + i%26))
+	if i >= 26 {
+		name += strconv.Itoa(i/26 + 1)
+	}
+	return name
+}
+
+// normalize lower-cases s and reduces it to words separated by single
+// spaces, so punctuation and spacing differences don't count.
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func bigrams(s string) map[string]int {
+	grams := map[string]int{}
+	runes := []rune(s)
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// dice is the Sørensen–Dice coefficient of two bigram multisets.
+func dice(a, b map[string]int) float64 {
+	total := 0
+	for _, n := range a {
+		total += n
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 1
+	}
+
+	shared := 0
+	for gram, n := range a {
+		shared += min(n, b[gram])
+	}
+	return 2 * float64(shared) / float64(total)
+}
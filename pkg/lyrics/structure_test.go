@@ -0,0 +1,84 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectStructure(t *testing.T) {
+	stanzas := Parse(`Walking down the road
+Thinking of you
+
+Sun is shining bright
+All day long
+
+Walking down the road
+Dreaming of nothing at all tonight
+
+Sun is shining bright!
+All day long
+
+Sun is shining bright
+All  day long`)
+
+	structure := DetectStructure(stanzas)
+
+	if structure.Pattern != "A B C B B" {
+		t.Errorf("Pattern = %q, want %q", structure.Pattern, "A B C B B")
+	}
+	wantSections := []Section{
+		{Name: "A", Stanzas: []int{0}},
+		{Name: "B", Role: RoleChorus, Stanzas: []int{1, 3, 4}},
+		{Name: "C", Stanzas: []int{2}},
+	}
+	if !reflect.DeepEqual(structure.Sections, wantSections) {
+		t.Errorf("Sections = %+v, want %+v", structure.Sections, wantSections)
+	}
+
+	if stanzas[3].Section != "B" || stanzas[3].Role != RoleChorus || stanzas[3].RepeatOf == nil || *stanzas[3].RepeatOf != 1 {
+		t.Errorf("stanza 3 = %+v, want a chorus repeating stanza 1", stanzas[3])
+	}
+	if stanzas[1].RepeatOf != nil {
+		t.Errorf("stanza 1 RepeatOf = %v, want nil for the first of its section", *stanzas[1].RepeatOf)
+	}
+
+	wantLines := []RepeatedLine{{Text: "Walking down the road", Positions: []LinePosition{{Verse: 0, Line: 0}, {Verse: 2, Line: 0}}}}
+	if !reflect.DeepEqual(structure.RepeatedLines, wantLines) {
+		t.Errorf("RepeatedLines = %+v, want %+v", structure.RepeatedLines, wantLines)
+	}
+}
+
+func TestDetectStructureRoles(t *testing.T) {
+	stanzas := Parse(`[Refrain]
+Oh la la
+
+Verse one here
+
+[Refrain]
+Oh la la
+
+Something else entirely
+
+Something else entirely`)
+
+	structure := DetectStructure(stanzas)
+
+	roles := map[string]string{}
+	for _, section := range structure.Sections {
+		roles[section.Name] = section.Role
+	}
+	// The label makes A a refrain; with no chorus label, the other repeated
+	// section becomes the chorus candidate.
+	want := map[string]string{"A": RoleRefrain, "B": "", "C": RoleChorus}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles = %v, want %v", roles, want)
+	}
+}
+
+func TestSectionName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "A2", 27: "B2", 52: "A3"} {
+		if got := sectionName(i); got != want {
+			t.Errorf("sectionName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Format is the media type of a patch document.
+type Format string
+
+const (
+	MergePatch Format = "application/merge-patch+json"
+	JSONPatch  Format = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported patch format")
+	ErrInvalidPatch      = errors.New("invalid patch")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does
+	// not match the document.
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Formats lists the supported patch formats, e.g. for an Accept-Patch
+// header.
+func Formats() []Format {
+	return []Format{MergePatch, JSONPatch}
+}
+
+// ParseFormat returns the patch format for a Content-Type media type.
+func ParseFormat(mediaType string) (Format, error) {
+	switch f := Format(mediaType); f {
+	case MergePatch, JSONPatch:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnsupportedFormat, mediaType)
+	}
+}
+
+// Apply applies patch, written in the given format, to the JSON document
+// doc and returns the patched document.
+func Apply(format Format, doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch format {
+	case MergePatch:
+		var p interface{}
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		target = merge(target, p)
+	case JSONPatch:
+		var ops []operation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		for i, op := range ops {
+			if target, err = op.apply(target); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
+	}
+
+	return json.Marshal(target)
+}
+
+// merge implements the MergePatch algorithm of RFC 7396: objects are merged
+// member by member, null removes a member and any other value replaces the
+// target.
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = merge(t[key], value)
+	}
+	return t
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (o operation) apply(doc interface{}) (interface{}, error) {
+	if o.Path == nil {
+		return nil, fmt.Errorf("%w: %q is missing path", ErrInvalidPatch, o.Op)
+	}
+	path, err := parsePointer(*o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if len(o.Value) == 0 {
+			return nil, fmt.Errorf("%w: %q is missing value", ErrInvalidPatch, o.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(o.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch o.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %q differs", ErrTestFailed, *o.Path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return remove(doc, path)
+
+	case "move", "copy":
+		if o.From == nil {
+			return nil, fmt.Errorf("%w: %q is missing from", ErrInvalidPatch, o.Op)
+		}
+		from, err := parsePointer(*o.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "copy" {
+			return add(doc, path, deepCopy(value))
+		}
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPatch, *o.From)
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, o.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped
+// reference tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token. "-" refers to the position after
+// the last element and is only valid where end is allowed.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("%w: array index %d is out of range", ErrInvalidPatch, i)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: cannot descend into a scalar at %q", ErrInvalidPatch, token)
+		}
+	}
+	return doc, nil
+}
+
+// add inserts value at path and returns the updated document. The parent
+// of path must exist; object members are set and array elements inserted.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+		}
+		child, err := add(child, rest, value)
+		node[token] = child
+		return node, err
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		node[i], err = add(node[i], rest, value)
+		return node, err
+	default:
+		return nil, fmt.Errorf("%w: cannot descend into a scalar at %q", ErrInvalidPatch, token)
+	}
+}
+
+// remove deletes the value at path, which must exist, and returns the
+// updated document.
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, nil
+		}
+		child, err := remove(child, rest)
+		node[token] = child
+		return node, err
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			return append(node[:i], node[i+1:]...), nil
+		}
+		node[i], err = remove(node[i], rest)
+		return node, err
+	default:
+		return nil, fmt.Errorf("%w: cannot descend into a scalar at %q", ErrInvalidPatch, token)
+	}
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c[key] = deepCopy(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = deepCopy(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// The cases follow the examples of RFC 6902 Appendix A.
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add nested object", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"unknown operation members ignored", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"escape ordering", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"add array value", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"replace whole document", `{"foo":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(JSONPatch, []byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  error
+	}{
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+		{"test number against string", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ErrTestFailed},
+		{"add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrInvalidPatch},
+		{"invalid array index", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"x"}]`, ErrInvalidPatch},
+		{"array index out of range", `{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/1"}]`, ErrInvalidPatch},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ErrInvalidPatch},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, ErrInvalidPatch},
+		{"pointer without slash", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ErrInvalidPatch},
+		{"missing path", `{"foo":"bar"}`, `[{"op":"remove"}]`, ErrInvalidPatch},
+		{"missing value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ErrInvalidPatch},
+		{"missing from", `{"foo":"bar"}`, `[{"op":"move","path":"/baz"}]`, ErrInvalidPatch},
+		{"move into own child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/x"}]`, ErrInvalidPatch},
+		{"unknown operation", `{"foo":"bar"}`, `[{"op":"frobnicate","path":"/foo"}]`, ErrInvalidPatch},
+		{"not an array", `{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`, ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply(JSONPatch, []byte(tt.doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+				t.Errorf("Apply error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// The cases follow the examples of RFC 7396 Appendix A.
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			got, err := Apply(MergePatch, []byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range Formats() {
+		if got, err := ParseFormat(string(format)); err != nil || got != format {
+			t.Errorf("ParseFormat(%q) = %q, %v", format, got, err)
+		}
+	}
+	if _, err := ParseFormat("application/json"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("ParseFormat(application/json) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("expected value is not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType int
+
+const (
+	// Text fields match substrings case-insensitively with ":" and whole
+	// values with "=".
+	Text FieldType = iota
+	// Keyword fields only match whole values, case-insensitively.
+	Keyword
+	// Number fields accept integers and all comparison operators.
+	Number
+	// Date fields accept YYYY-MM-DD values and all comparison operators.
+	Date
+	// Array fields match when any element equals the value.
+	Array
+)
+
+// Field maps a query field onto a SQL expression.
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// Schema lists the fields a query may reference. Bare terms are matched
+// against the Default fields.
+type Schema struct {
+	Fields  map[string]Field
+	Default []string
+}
+
+// Validate checks that every term refers to a known field and carries a
+// value and operator that suit the field's type.
+func (s Schema) Validate(node Node) error {
+	_, _, err := s.Compile(node, 1)
+	return err
+}
+
+// Compile translates the query into a SQL condition. Values are passed as
+// positional arguments numbered from argIndex.
+func (s Schema) Compile(node Node, argIndex int) (string, []interface{}, error) {
+	c := &compiler{schema: s, argIndex: argIndex}
+	sql, err := c.compile(node)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+type compiler struct {
+	schema   Schema
+	argIndex int
+	args     []interface{}
+}
+
+func (c *compiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	c.argIndex++
+	return "$" + strconv.Itoa(c.argIndex-1)
+}
+
+func (c *compiler) compile(node Node) (string, error) {
+	switch n := node.(type) {
+	case *And:
+		return c.binary(n.Left, n.Right, "AND")
+	case *Or:
+		return c.binary(n.Left, n.Right, "OR")
+	case *Not:
+		expr, err := c.compile(n.Expr)
+		if err != nil {
+			return "", err
+		}
+		// NULL comparisons would otherwise drop rows from both a term and
+		// its negation.
+		return "NOT COALESCE(" + expr + ", FALSE)", nil
+	case *Term:
+		if n.Field == "" {
+			return c.bare(n)
+		}
+		return c.term(n)
+	}
+	return "", fmt.Errorf("query: unsupported node %T", node)
+}
+
+func (c *compiler) binary(left, right Node, op string) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+func (c *compiler) bare(t *Term) (string, error) {
+	if len(c.schema.Default) == 0 {
+		return "", &Error{Pos: t.ValuePos, Msg: "a field is required, e.g. field:value"}
+	}
+
+	var parts []string
+	for _, name := range c.schema.Default {
+		part, err := c.term(&Term{Field: name, FieldPos: t.ValuePos, Op: ":", Value: t.Value, ValuePos: t.ValuePos, Phrase: t.Phrase})
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", nil
+}
+
+func (c *compiler) term(t *Term) (string, error) {
+	field, ok := c.schema.Fields[strings.ToLower(t.Field)]
+	if !ok {
+		return "", &Error{Pos: t.FieldPos, Msg: fmt.Sprintf("unknown field %q", t.Field)}
+	}
+
+	switch field.Type {
+	case Text, Keyword:
+		switch {
+		case t.Op == ":" && field.Type == Text:
+			return field.Column + " ILIKE " + c.arg("%"+escapeLike(t.Value)+"%"), nil
+		case t.Op == ":" || t.Op == "=":
+			return "lower(" + field.Column + ") = lower(" + c.arg(t.Value) + ")", nil
+		case t.Op == "!=":
+			return "lower(" + field.Column + ") <> lower(" + c.arg(t.Value) + ")", nil
+		}
+
+	case Array:
+		switch t.Op {
+		case ":", "=":
+			return "EXISTS (SELECT 1 FROM unnest(" + field.Column + ") AS elem WHERE lower(elem) = lower(" + c.arg(t.Value) + "))", nil
+		case "!=":
+			return "NOT EXISTS (SELECT 1 FROM unnest(" + field.Column + ") AS elem WHERE lower(elem) = lower(" + c.arg(t.Value) + "))", nil
+		}
+
+	case Number:
+		n, err := strconv.Atoi(t.Value)
+		if err != nil {
+			return "", &Error{Pos: t.ValuePos, Msg: fmt.Sprintf("field %q expects a whole number, got %q", t.Field, t.Value)}
+		}
+		return field.Column + " " + sqlOp(t.Op) + " " + c.arg(n), nil
+
+	case Date:
+		if _, err := time.Parse("2006-01-02", t.Value); err != nil {
+			return "", &Error{Pos: t.ValuePos, Msg: fmt.Sprintf("field %q expects a date as YYYY-MM-DD, got %q", t.Field, t.Value)}
+		}
+		return field.Column + " " + sqlOp(t.Op) + " " + c.arg(t.Value), nil
+	}
+
+	return "", &Error{Pos: t.FieldPos, Msg: fmt.Sprintf("operator %q is not supported for field %q", t.Op, t.Field)}
+}
+
+func sqlOp(op string) string {
+	switch op {
+	case ":":
+		return "="
+	case "!=":
+		return "<>"
+	}
+	return op
+}
+
+// escapeLike escapes the LIKE wildcards so values are matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,148 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of query"
+	case tokenWord:
+		return "word"
+	case tokenString:
+		return "quoted phrase"
+	case tokenOp:
+		return "operator"
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	}
+	return "token"
+}
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	space bool // whether whitespace preceded the token
+}
+
+// lex splits the input into tokens. Positions are 1-based rune offsets so
+// they can be shown to users as-is.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+
+	i := 0
+	for {
+		start := i
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+		space := i > start
+		if i >= len(runes) {
+			tokens = append(tokens, token{kind: tokenEOF, pos: i + 1, space: space})
+			return tokens, nil
+		}
+
+		pos := i + 1
+		switch r := runes[i]; {
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos, space: space})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos, space: space})
+			i++
+		case r == ':' || r == '=':
+			tokens = append(tokens, token{kind: tokenOp, text: string(r), pos: pos, space: space})
+			i++
+		case r == '<' || r == '>' || r == '!':
+			op := string(r)
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				op += "="
+				i++
+			}
+			if op == "!" {
+				return nil, &Error{Pos: pos, Msg: "expected '=' after '!'"}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos, space: space})
+		case r == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, &Error{Pos: pos, Msg: "unterminated quoted phrase"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: pos, space: space})
+		default:
+			// A value written right after a field's operator runs up to the
+			// next space or parenthesis, so it may itself contain ':' or
+			// '=', as in link:https://example.com.
+			delimiter := isDelimiter
+			if n := len(tokens); n > 0 && tokens[n-1].kind == tokenOp && !space {
+				delimiter = isValueDelimiter
+			}
+			wordStart := i
+			for i < len(runes) && !delimiter(runes[i]) {
+				i++
+			}
+			word := string(runes[wordStart:i])
+			tok := token{kind: tokenWord, text: word, pos: pos, space: space}
+			switch word {
+			case "AND", "&&":
+				tok.kind = tokenAnd
+			case "OR", "||":
+				tok.kind = tokenOr
+			case "NOT":
+				tok.kind = tokenNot
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`()":=<>!`, r)
+}
+
+func isValueDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')'
+}
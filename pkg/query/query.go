@@ -0,0 +1,233 @@
+// Package query implements a small search language for filtering records,
+// e.g. `artist:rammstein AND year>=1997 AND NOT lyrics:"sonne"`.
+//
+// Terms are either bare values or field-qualified comparisons
+// (field:value, field=value, field!=value, field<value, ...). Terms can be
+// combined with AND, OR, NOT and parentheses; adjacent terms without an
+// operator are ANDed together. Only the first operator of a term splits it,
+// so values may contain ':' or '='; values with spaces or parentheses are
+// quoted, e.g. title:"hey: you".
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error describes a problem with a query together with the 1-based rune
+// position it was found at.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Pos, e.Msg)
+}
+
+type Node interface {
+	Pos() int
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Expr Node
+	At   int
+}
+
+// Term matches a single value. Field is empty for bare terms, which are
+// matched against the schema's default fields.
+type Term struct {
+	Field    string
+	FieldPos int
+	Op       string
+	Value    string
+	ValuePos int
+	Phrase   bool
+}
+
+func (n *And) Pos() int { return n.Left.Pos() }
+func (n *Or) Pos() int  { return n.Left.Pos() }
+func (n *Not) Pos() int { return n.At }
+
+func (n *Term) Pos() int {
+	if n.Field != "" {
+		return n.FieldPos
+	}
+	return n.ValuePos
+}
+
+// Parse parses a query into its syntax tree.
+func Parse(input string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &Error{Pos: p.peek().pos, Msg: "empty query"}
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", describe(tok))}
+	}
+
+	return node, nil
+}
+
+// Without removes the terms on any of fields from the top-level
+// conjunction of node, so that the constraints on those fields can be
+// relaxed while the rest still apply. Terms under OR or NOT are kept, as
+// removing them would change what the rest of the query matches. Field
+// names compare case-insensitively. It returns nil if no term is left.
+func Without(node Node, fields ...string) Node {
+	switch n := node.(type) {
+	case *And:
+		left, right := Without(n.Left, fields...), Without(n.Right, fields...)
+		switch {
+		case left == nil:
+			return right
+		case right == nil:
+			return left
+		}
+		return &And{Left: left, Right: right}
+	case *Term:
+		for _, field := range fields {
+			if n.Field != "" && strings.EqualFold(n.Field, field) {
+				return nil
+			}
+		}
+	}
+	return node
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenWord, tokenString, tokenNot, tokenLParen:
+			// implicit AND between adjacent terms
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if tok := p.peek(); tok.kind == tokenNot {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr, At: tok.pos}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected ')' to close '(' at position %d, found %s", tok.pos, describe(closing))}
+		}
+		return node, nil
+
+	case tokenString:
+		return &Term{Value: tok.text, ValuePos: tok.pos, Phrase: true}, nil
+
+	case tokenWord:
+		if op := p.peek(); op.kind == tokenOp && !op.space {
+			p.next()
+			value := p.next()
+			if (value.kind != tokenWord && value.kind != tokenString) || value.space {
+				return nil, &Error{Pos: value.pos, Msg: fmt.Sprintf("expected value after %q", tok.text+op.text)}
+			}
+			return &Term{
+				Field:    tok.text,
+				FieldPos: tok.pos,
+				Op:       op.text,
+				Value:    value.text,
+				ValuePos: value.pos,
+				Phrase:   value.kind == tokenString,
+			}, nil
+		}
+		return &Term{Value: tok.text, ValuePos: tok.pos}, nil
+	}
+
+	return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", describe(tok))}
+}
+
+func describe(tok token) string {
+	if tok.text != "" && tok.kind != tokenString {
+		return fmt.Sprintf("%q", tok.text)
+	}
+	return tok.kind.String()
+}
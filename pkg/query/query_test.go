@@ -0,0 +1,189 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSchema = Schema{
+	Fields: map[string]Field{
+		"artist": {Column: "group_name", Type: Text},
+		"lang":   {Column: "language", Type: Keyword},
+		"year":   {Column: "year", Type: Number},
+		"date":   {Column: "release_date", Type: Date},
+		"tag":    {Column: "tags", Type: Array},
+		"link":   {Column: "link", Type: Text},
+	},
+	Default: []string{"artist"},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Node
+	}{
+		{`sonne`, &Term{Value: "sonne", ValuePos: 1}},
+		{`"hier kommt"`, &Term{Value: "hier kommt", ValuePos: 1, Phrase: true}},
+		{`year>=1997`, &Term{Field: "year", FieldPos: 1, Op: ">=", Value: "1997", ValuePos: 7}},
+		{`link:https://example.com/a?b=c`, &Term{Field: "link", FieldPos: 1, Op: ":", Value: "https://example.com/a?b=c", ValuePos: 6}},
+		{`artist:"AC/DC: live"`, &Term{Field: "artist", FieldPos: 1, Op: ":", Value: "AC/DC: live", ValuePos: 8, Phrase: true}},
+		{`artist:"say \"hi\""`, &Term{Field: "artist", FieldPos: 1, Op: ":", Value: `say "hi"`, ValuePos: 8, Phrase: true}},
+		{`a b`, &And{Left: &Term{Value: "a", ValuePos: 1}, Right: &Term{Value: "b", ValuePos: 3}}},
+		{`a OR b AND c`, &Or{
+			Left:  &Term{Value: "a", ValuePos: 1},
+			Right: &And{Left: &Term{Value: "b", ValuePos: 6}, Right: &Term{Value: "c", ValuePos: 12}},
+		}},
+		{`NOT (a || tag=x)`, &Not{At: 1, Expr: &Or{
+			Left:  &Term{Value: "a", ValuePos: 6},
+			Right: &Term{Field: "tag", FieldPos: 11, Op: "=", Value: "x", ValuePos: 15},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{``, 1},
+		{`   `, 4},
+		{`(a`, 3},
+		{`a)`, 2},
+		{`artist:`, 8},
+		{`artist: x`, 9},
+		{`year!1997`, 5},
+		{`"unterminated`, 1},
+		{`a AND`, 6},
+		{`NOT`, 4},
+		{`:x`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var queryErr *Error
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at %d, want %d (%v)", tt.input, queryErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{`sonne`, `(group_name ILIKE $3)`, []interface{}{"%sonne%"}},
+		{`artist:50%_off`, `group_name ILIKE $3`, []interface{}{`%50\%\_off%`}},
+		{`artist=rammstein`, `lower(group_name) = lower($3)`, []interface{}{"rammstein"}},
+		{`lang:de`, `lower(language) = lower($3)`, []interface{}{"de"}},
+		{`year>=1997 year<2000`, `(year >= $3 AND year < $4)`, []interface{}{1997, 2000}},
+		{`NOT tag:rock`, `NOT COALESCE(EXISTS (SELECT 1 FROM unnest(tags) AS elem WHERE lower(elem) = lower($3)), FALSE)`, []interface{}{"rock"}},
+		{`date:2001-09-11`, `release_date = $3`, []interface{}{"2001-09-11"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			sql, args, err := testSchema.Compile(node, 3)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if sql != tt.sql || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Compile(%q) = %q %v, want %q %v", tt.input, sql, args, tt.sql, tt.args)
+			}
+		})
+	}
+}
+
+func TestWithout(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+	}{
+		{`Artist:x year>=1997 lang:de`, `(year >= $3 AND lower(language) = lower($4))`},
+		{`artist:x OR year>=1997`, `(group_name ILIKE $3 OR year >= $4)`},
+		{`NOT artist:x year>=1997`, `(NOT COALESCE(group_name ILIKE $3, FALSE) AND year >= $4)`},
+		{`x artist:y`, `(group_name ILIKE $3)`},
+		{`artist:x artist:y`, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			node = Without(node, "artist")
+			if node == nil {
+				if tt.sql != "" {
+					t.Fatalf("Without(%q) = nil, want %q", tt.input, tt.sql)
+				}
+				return
+			}
+			sql, _, err := testSchema.Compile(node, 3)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("Without(%q) compiles to %q, want %q", tt.input, sql, tt.sql)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{`genre:rock`, 1},
+		{`year:nineteen`, 6},
+		{`date:yesterday`, 6},
+		{`lang>de`, 1},
+		{`tag<x`, 1},
+		{`a OR genre:x`, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			err = testSchema.Validate(node)
+			var queryErr *Error
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Validate(%q) error = %v, want *Error", tt.input, err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("Validate(%q) error at %d, want %d (%v)", tt.input, queryErr.Pos, tt.pos, err)
+			}
+		})
+	}
+
+	if err := (Schema{Fields: testSchema.Fields}).Validate(&Term{Value: "x", ValuePos: 1}); err == nil {
+		t.Error("Validate of a bare term without default fields succeeded")
+	}
+}
@@ -4,10 +4,28 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-type JSONError struct {
-	Status  int    `json:"status"`
+// Problem is an RFC 7807 problem details object. RequestID echoes the ID
+// the request was logged under; Errors lists the offending fields of an
+// invalid input.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []InvalidField `json:"errors,omitempty"`
+}
+
+// InvalidField describes why one field of an input was rejected. Code is a
+// stable machine-readable reason, e.g. "required" or "too_long".
+type InvalidField struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
@@ -18,16 +36,27 @@ func Err(err error) slog.Attr {
 	}
 }
 
-func RespondWithErrorJSON(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	jsonError := JSONError{
-		Status:  status,
-		Message: message,
+// RespondWithProblem writes problem as application/problem+json. An unset
+// type defaults to "about:blank" and an unset title to the status text.
+func RespondWithProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
 	}
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
 
-	json.NewEncoder(w).Encode(jsonError)
+// RespondWithErrorJSON writes a problem with the given status and message
+// as its detail.
+func RespondWithErrorJSON(w http.ResponseWriter, r *http.Request, status int, message string) {
+	RespondWithProblem(w, r, Problem{Status: status, Detail: message})
 }
 
 func RespondWithJSON(w http.ResponseWriter, status int, data interface{}) {